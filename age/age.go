@@ -0,0 +1,162 @@
+// Package age implements the age v1 file encryption format (age-encryption.org/v1)
+// with libsodium primitives.
+//
+// A file is encrypted with a random file key, which is wrapped for every recipient
+// in a stanza of the header: with an ephemeral X25519 key exchange for X25519
+// recipients, or with a key derived from a passphrase with scrypt. The header is
+// authenticated with an HMAC-SHA-256 under a key derived from the file key, and
+// the payload is encrypted in 64 KiB ChaCha20-Poly1305 chunks under a key derived
+// from the file key and a random nonce.
+//
+// Files produced by this package can be decrypted by other age implementations,
+// and the other way around. The ASCII armored format is not supported.
+package age
+
+import (
+	"bufio"
+	"errors"
+	"github.com/GoKillers/libsodium-go/randombytes"
+	"github.com/GoKillers/libsodium-go/sodium"
+	"io"
+)
+
+// Sodium should always be initialised
+func init() {
+	sodium.Initialize()
+}
+
+const fileKeySize = 16
+
+// Recipient wraps a file key for a recipient in one or more stanzas.
+type Recipient interface {
+	Wrap(fileKey []byte) ([]*Stanza, error)
+}
+
+// Identity unwraps a file key from a stanza. It returns ErrIncorrectIdentity if
+// the stanza is not addressed to it; any other error aborts decryption.
+type Identity interface {
+	Unwrap(s *Stanza) ([]byte, error)
+}
+
+var (
+	// ErrIncorrectIdentity is returned by Identity.Unwrap for stanzas it can not unwrap.
+	ErrIncorrectIdentity = errors.New("age: incorrect identity for stanza")
+
+	// ErrNoIdentityMatch is returned by Decrypt when no identity can unwrap the file key.
+	ErrNoIdentityMatch = errors.New("age: no identity matched any of the recipients")
+
+	errMAC = errors.New("age: header MAC verification failed")
+)
+
+// Encrypt writes the header of a file encrypted for the recipients to `dst`, and
+// returns a writer that encrypts the payload. The writer must be closed to write
+// the last chunk; it does not close `dst`.
+func Encrypt(dst io.Writer, recipients ...Recipient) (io.WriteCloser, error) {
+	if len(recipients) == 0 {
+		return nil, errors.New("age: no recipients")
+	}
+
+	fileKey := randombytes.RandomBytes(fileKeySize)
+	defer sodium.MemZero(fileKey)
+
+	var stanzas []*Stanza
+	for _, r := range recipients {
+		s, err := r.Wrap(fileKey)
+		if err != nil {
+			return nil, err
+		}
+		stanzas = append(stanzas, s...)
+	}
+
+	if err := checkScrypt(stanzas); err != nil {
+		return nil, err
+	}
+
+	h := marshalHeader(stanzas)
+	h = append(h, ' ')
+	h = append(h, b64.EncodeToString(headerMAC(fileKey, h[:len(h)-1]))...)
+	h = append(h, '\n')
+
+	nonce := randombytes.RandomBytes(payloadNonceSize)
+	h = append(h, nonce...)
+
+	if _, err := dst.Write(h); err != nil {
+		return nil, err
+	}
+
+	return newStreamWriter(dst, payloadKey(fileKey, nonce)), nil
+}
+
+// Decrypt reads the header of an encrypted file from `src`, unwraps the file key with
+// the first identity that matches one of the stanzas, and returns a reader that
+// decrypts and verifies the payload. The reader returns an error if the payload has
+// been modified or truncated, so data read before such an error must not be trusted
+// until the reader returns io.EOF.
+func Decrypt(src io.Reader, identities ...Identity) (io.Reader, error) {
+	if len(identities) == 0 {
+		return nil, errors.New("age: no identities")
+	}
+
+	r := bufio.NewReader(src)
+
+	h, err := parseHeader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkScrypt(h.stanzas); err != nil {
+		return nil, err
+	}
+
+	fileKey, err := unwrap(h.stanzas, identities)
+	if err != nil {
+		return nil, err
+	}
+	defer sodium.MemZero(fileKey)
+
+	if !verifyHeaderMAC(fileKey, h) {
+		return nil, errMAC
+	}
+
+	nonce := make([]byte, payloadNonceSize)
+	if _, err := io.ReadFull(r, nonce); err != nil {
+		return nil, errPayload
+	}
+
+	return newStreamReader(r, payloadKey(fileKey, nonce)), nil
+}
+
+// unwrap returns the file key from the first stanza one of the identities can unwrap.
+func unwrap(stanzas []*Stanza, identities []Identity) ([]byte, error) {
+	for _, id := range identities {
+		for _, s := range stanzas {
+			fileKey, err := id.Unwrap(s)
+			if err == ErrIncorrectIdentity {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+
+			if len(fileKey) != fileKeySize {
+				return nil, errors.New("age: invalid file key")
+			}
+
+			return fileKey, nil
+		}
+	}
+
+	return nil, ErrNoIdentityMatch
+}
+
+// checkScrypt checks that an scrypt stanza is the only stanza of a header, so that a
+// passphrase-encrypted file can not also be decrypted, or modified, by public key recipients.
+func checkScrypt(stanzas []*Stanza) error {
+	for _, s := range stanzas {
+		if s.Type == "scrypt" && len(stanzas) != 1 {
+			return errors.New("age: an scrypt stanza must be the only stanza")
+		}
+	}
+
+	return nil
+}
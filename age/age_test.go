@@ -0,0 +1,265 @@
+package age
+
+import (
+	"bytes"
+	"compress/zlib"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testVector is a file of the age test kit in testdata/testkit: a list of
+// "key: value" lines, an empty line, and the encrypted file, optionally
+// compressed with zlib.
+type testVector struct {
+	expect      string
+	payload     string
+	identities  []string
+	passphrases []string
+	file        []byte
+}
+
+func readTestVector(t *testing.T, path string) *testVector {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	i := bytes.Index(data, []byte("\n\n"))
+	v := &testVector{file: data[i+2:]}
+
+	for _, line := range strings.Split(string(data[:i]), "\n") {
+		key, value, _ := strings.Cut(line, ": ")
+		switch key {
+		case "expect":
+			v.expect = value
+		case "payload":
+			v.payload = value
+		case "identity":
+			v.identities = append(v.identities, value)
+		case "passphrase":
+			v.passphrases = append(v.passphrases, value)
+		case "compressed":
+			if value != "zlib" {
+				t.Fatalf("Unknown compression %q", value)
+			}
+
+			r, err := zlib.NewReader(bytes.NewReader(v.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if v.file, err = io.ReadAll(r); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	return v
+}
+
+func TestVectors(t *testing.T) {
+	paths, _ := filepath.Glob("testdata/testkit/*")
+	if len(paths) == 0 {
+		t.Fatal("No test vectors")
+	}
+
+	for _, path := range paths {
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			v := readTestVector(t, path)
+
+			var ids []Identity
+			for _, s := range v.identities {
+				id, err := ParseX25519Identity(s)
+				if err != nil {
+					t.Fatal(err)
+				}
+				ids = append(ids, id)
+			}
+			for _, s := range v.passphrases {
+				id, err := NewScryptIdentity(s)
+				if err != nil {
+					t.Fatal(err)
+				}
+				ids = append(ids, id)
+			}
+
+			r, err := Decrypt(bytes.NewReader(v.file), ids...)
+
+			switch v.expect {
+			case "no match":
+				if err != ErrNoIdentityMatch {
+					t.Fatalf("Expected no match, got %v", err)
+				}
+				return
+			case "HMAC failure":
+				if err != errMAC {
+					t.Fatalf("Expected HMAC failure, got %v", err)
+				}
+				return
+			case "header failure":
+				if err == nil {
+					t.Fatal("Expected header failure")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// On a payload failure, the payload is what was released before it
+			p, err := io.ReadAll(r)
+			if v.expect == "payload failure" {
+				if err != errPayload {
+					t.Fatalf("Expected payload failure, got %v", err)
+				}
+			} else if err != nil {
+				t.Fatal(err)
+			}
+
+			if v.payload == "" {
+				return
+			}
+			if sum := sha256.Sum256(p); hex.EncodeToString(sum[:]) != v.payload {
+				t.Fatal("Wrong payload")
+			}
+		})
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	alice, bob := GenerateX25519Identity(), GenerateX25519Identity()
+
+	for _, size := range []int{0, 1, chunkSize - 1, chunkSize, chunkSize + 1, 3 * chunkSize} {
+		m := bytes.Repeat([]byte{0x42}, size)
+
+		var buf bytes.Buffer
+		w, err := Encrypt(&buf, alice.Recipient(), bob.Recipient())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Write in uneven pieces across chunk boundaries
+		for p := m; len(p) > 0; {
+			n := len(p)
+			if n > 1000 {
+				n = 1000
+			}
+			if _, err := w.Write(p[:n]); err != nil {
+				t.Fatal(err)
+			}
+			p = p[n:]
+		}
+
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		for _, id := range []*X25519Identity{alice, bob} {
+			r, err := Decrypt(bytes.NewReader(buf.Bytes()), id)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if p, err := io.ReadAll(r); err != nil || !bytes.Equal(p, m) {
+				t.Fatalf("Decryption of %d bytes failed: %v", size, err)
+			}
+		}
+
+		if _, err := Decrypt(bytes.NewReader(buf.Bytes()), GenerateX25519Identity()); err != ErrNoIdentityMatch {
+			t.Fatalf("Expected ErrNoIdentityMatch, got %v", err)
+		}
+	}
+}
+
+func TestScrypt(t *testing.T) {
+	r, _ := NewScryptRecipient("correct horse battery staple")
+	r.SetWorkFactor(10)
+
+	var buf bytes.Buffer
+	w, _ := Encrypt(&buf, r)
+	w.Write([]byte("test message"))
+	w.Close()
+
+	id, _ := NewScryptIdentity("correct horse battery staple")
+	d, err := Decrypt(bytes.NewReader(buf.Bytes()), id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if p, _ := io.ReadAll(d); string(p) != "test message" {
+		t.Fatal("Wrong message")
+	}
+
+	id.SetMaxWorkFactor(9)
+	if _, err := Decrypt(bytes.NewReader(buf.Bytes()), id); err == nil {
+		t.Fatal("Work factor above the maximum accepted")
+	}
+
+	if _, err := Encrypt(io.Discard, r, GenerateX25519Identity().Recipient()); err == nil {
+		t.Fatal("scrypt recipient mixed with other recipients")
+	}
+}
+
+func TestKeyEncoding(t *testing.T) {
+	// A fixed key pair
+	const identity = "AGE-SECRET-KEY-13SYTYMHW2KYV982H9EVDGFUWLNUQPULHPAVGXMZ9VCFV7J0TCPTQFRRT3W"
+	const recipient = "age1fu8fd4s9nt4mmc7awv2cahl2rmv7awgfj5navlqv8wl2p4lve4jsh8y4f2"
+
+	id, err := ParseX25519Identity(identity)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if id.String() != identity {
+		t.Fatal("Identity encoding does not round trip")
+	}
+
+	if id.Recipient().String() != recipient {
+		t.Fatalf("Wrong recipient %s", id.Recipient())
+	}
+
+	r, err := ParseX25519Recipient(recipient)
+	if err != nil || !bytes.Equal(r.pk, id.pk) {
+		t.Fatalf("Recipient encoding does not round trip: %v", err)
+	}
+
+	for _, s := range []string{
+		strings.ToLower(identity[:20]) + identity[20:], // Mixed case
+		identity[:len(identity)-1] + "Q",               // Wrong checksum
+		recipient,                                      // Recipient as identity
+	} {
+		if _, err := ParseX25519Identity(s); err == nil {
+			t.Fatalf("Invalid identity %q accepted", s)
+		}
+	}
+}
+
+func TestBech32(t *testing.T) {
+	// Valid strings from BIP 173
+	for _, s := range []string{
+		"A12UEL5L",
+		"a12uel5l",
+		"abcdef1qpzry9x8gf2tvdw0s3jn54khce6mua7lmqqqxw",
+		"split1checkupstagehandshakeupstreamerranterredcaperred2y9e3w",
+	} {
+		hrp, _, err := bech32Decode(s)
+		if err != nil {
+			t.Fatalf("%q: %v", s, err)
+		}
+
+		if hrp != strings.ToLower(s[:strings.LastIndexByte(s, '1')]) {
+			t.Fatalf("%q: wrong human-readable part %q", s, hrp)
+		}
+	}
+
+	for _, s := range []string{"A1G7SGD8", "10a06t8", "1qzzfhee", "li1dgmt3"} {
+		if _, _, err := bech32Decode(s); err == nil {
+			t.Fatalf("Invalid string %q accepted", s)
+		}
+	}
+}
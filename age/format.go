@@ -0,0 +1,224 @@
+package age
+
+// #cgo pkg-config: libsodium
+// #include <stdlib.h>
+// #include <sodium.h>
+import "C"
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"github.com/GoKillers/libsodium-go/cryptokdf/hkdfsha256"
+	"io"
+	"strings"
+)
+
+const (
+	intro       = "age-encryption.org/v1\n"
+	stanzaStart = "-> "
+	footer      = "---"
+	columns     = 64 // Number of base64 characters in a full line of a stanza body
+	macBytes    = C.crypto_auth_hmacsha256_BYTES
+)
+
+var b64 = base64.RawStdEncoding.Strict()
+
+// decodeBase64 decodes canonical unpadded base64, which, unlike the decoder
+// of encoding/base64, does not accept line breaks.
+func decodeBase64(s string) ([]byte, error) {
+	if strings.ContainsAny(s, "\r\n") {
+		return nil, errors.New("age: invalid base64")
+	}
+
+	return b64.DecodeString(s)
+}
+
+// Stanza is a recipient stanza of a header: the file key wrapped for one recipient.
+type Stanza struct {
+	Type string
+	Args []string
+	Body []byte
+}
+
+// marshal appends the encoding of the stanza to `b`.
+func (s *Stanza) marshal(b []byte) []byte {
+	b = append(b, stanzaStart...)
+	b = append(b, s.Type...)
+	for _, a := range s.Args {
+		b = append(b, ' ')
+		b = append(b, a...)
+	}
+	b = append(b, '\n')
+
+	// The body is wrapped in full lines, followed by a line that is not full, possibly empty
+	body := b64.EncodeToString(s.Body)
+	for len(body) >= columns {
+		b = append(b, body[:columns]...)
+		b = append(b, '\n')
+		body = body[columns:]
+	}
+
+	b = append(b, body...)
+	return append(b, '\n')
+}
+
+// header is the header of an age file.
+type header struct {
+	stanzas []*Stanza
+	raw     []byte // The encoded header up to and including the footer, authenticated by the MAC
+	mac     []byte
+}
+
+// marshalHeader returns the encoded header for a list of stanzas, up to and including the footer.
+func marshalHeader(stanzas []*Stanza) []byte {
+	b := []byte(intro)
+	for _, s := range stanzas {
+		b = s.marshal(b)
+	}
+
+	return append(b, footer...)
+}
+
+// errHeader is returned when a header is malformed.
+var errHeader = errors.New("age: invalid header")
+
+// readLine reads a line terminated by a line feed, and returns it without the line feed.
+func readLine(r *bufio.Reader) ([]byte, error) {
+	line, err := r.ReadSlice('\n')
+	if err == io.EOF || err == bufio.ErrBufferFull {
+		return nil, errHeader
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return line[:len(line)-1], nil
+}
+
+// isArgument reports whether s is a valid stanza argument: a non-empty string of visible ASCII characters.
+func isArgument(s string) bool {
+	if s == "" {
+		return false
+	}
+
+	for i := 0; i < len(s); i++ {
+		if s[i] < 0x21 || s[i] > 0x7e {
+			return false
+		}
+	}
+
+	return true
+}
+
+// parseHeader reads a header from `r`. The payload follows in `r`.
+func parseHeader(r *bufio.Reader) (*header, error) {
+	h := &header{}
+	var raw bytes.Buffer
+
+	line, err := readLine(r)
+	if err != nil {
+		return nil, err
+	}
+	if string(line)+"\n" != intro {
+		return nil, errors.New("age: unsupported format")
+	}
+	raw.WriteString(intro)
+
+	for {
+		line, err := readLine(r)
+		if err != nil {
+			return nil, err
+		}
+
+		if bytes.HasPrefix(line, []byte(footer+" ")) {
+			raw.WriteString(footer)
+
+			mac, err := decodeBase64(string(line[len(footer)+1:]))
+			if err != nil || len(mac) != macBytes {
+				return nil, errHeader
+			}
+
+			h.mac = mac
+			break
+		}
+
+		if !bytes.HasPrefix(line, []byte(stanzaStart)) {
+			return nil, errHeader
+		}
+		raw.Write(line)
+		raw.WriteByte('\n')
+
+		args := strings.Split(string(line[len(stanzaStart):]), " ")
+		for _, a := range args {
+			if !isArgument(a) {
+				return nil, errHeader
+			}
+		}
+
+		s := &Stanza{Type: args[0], Args: args[1:]}
+
+		for {
+			line, err := readLine(r)
+			if err != nil {
+				return nil, err
+			}
+			raw.Write(line)
+			raw.WriteByte('\n')
+
+			if len(line) > columns {
+				return nil, errHeader
+			}
+
+			b, err := decodeBase64(string(line))
+			if err != nil {
+				return nil, errHeader
+			}
+			s.Body = append(s.Body, b...)
+
+			if len(line) < columns {
+				break
+			}
+		}
+
+		h.stanzas = append(h.stanzas, s)
+	}
+
+	if len(h.stanzas) == 0 {
+		return nil, errHeader
+	}
+
+	h.raw = raw.Bytes()
+
+	return h, nil
+}
+
+// hkdf derives a key of `length` bytes with HKDF-SHA-256.
+func hkdf(length int, ikm, salt []byte, info string) []byte {
+	return hkdfsha256.Expand(length, []byte(info), hkdfsha256.Extract(salt, ikm))
+}
+
+// headerMAC returns the MAC of an encoded header under a file key.
+func headerMAC(fileKey, raw []byte) []byte {
+	k := hkdf(C.crypto_auth_hmacsha256_KEYBYTES, fileKey, nil, "header")
+	mac := make([]byte, macBytes)
+
+	C.crypto_auth_hmacsha256(
+		(*C.uchar)(&mac[0]),
+		(*C.uchar)(&raw[0]),
+		(C.ulonglong)(len(raw)),
+		(*C.uchar)(&k[0]))
+
+	return mac
+}
+
+// verifyHeaderMAC reports whether the MAC of a header is valid under a file key.
+func verifyHeaderMAC(fileKey []byte, h *header) bool {
+	k := hkdf(C.crypto_auth_hmacsha256_KEYBYTES, fileKey, nil, "header")
+
+	return C.crypto_auth_hmacsha256_verify(
+		(*C.uchar)(&h.mac[0]),
+		(*C.uchar)(&h.raw[0]),
+		(C.ulonglong)(len(h.raw)),
+		(*C.uchar)(&k[0])) == 0
+}
@@ -0,0 +1,154 @@
+package age
+
+// #cgo pkg-config: libsodium
+// #include <stdlib.h>
+// #include <sodium.h>
+import "C"
+import (
+	"errors"
+	"github.com/GoKillers/libsodium-go/crypto/aead/chacha20poly1305ietf"
+	"github.com/GoKillers/libsodium-go/randombytes"
+	"github.com/GoKillers/libsodium-go/sodium"
+	"github.com/GoKillers/libsodium-go/support"
+	"strconv"
+)
+
+const (
+	scryptLabel    = "age-encryption.org/v1/scrypt"
+	scryptSaltSize = 16
+
+	// DefaultWorkFactor is the default base-2 logarithm of the scrypt cost N for encryption.
+	DefaultWorkFactor = 18
+
+	// DefaultMaxWorkFactor is the default highest work factor accepted for decryption.
+	DefaultMaxWorkFactor = 22
+)
+
+// scrypt derives the key wrapping the file key from a passphrase, with r = 8 and p = 1.
+func scrypt(passphrase, salt []byte, logN int) (*[chacha20poly1305ietf.KeyBytes]byte, error) {
+	s := append([]byte(scryptLabel), salt...)
+	k := new([chacha20poly1305ietf.KeyBytes]byte)
+
+	exit := C.crypto_pwhash_scryptsalsa208sha256_ll(
+		(*C.uint8_t)(support.BytePointer(passphrase)),
+		(C.size_t)(len(passphrase)),
+		(*C.uint8_t)(&s[0]),
+		(C.size_t)(len(s)),
+		(C.uint64_t)(1)<<uint(logN),
+		8,
+		1,
+		(*C.uint8_t)(&k[0]),
+		(C.size_t)(len(k)))
+
+	if exit != 0 {
+		return nil, errors.New("age: scrypt failed")
+	}
+
+	return k, nil
+}
+
+// ScryptRecipient wraps file keys with a passphrase. It must be the only recipient of a file.
+type ScryptRecipient struct {
+	passphrase []byte
+	workFactor int
+}
+
+// NewScryptRecipient returns a recipient for a non-empty passphrase, with the default work factor.
+func NewScryptRecipient(passphrase string) (*ScryptRecipient, error) {
+	if passphrase == "" {
+		return nil, errors.New("age: empty passphrase")
+	}
+
+	return &ScryptRecipient{passphrase: []byte(passphrase), workFactor: DefaultWorkFactor}, nil
+}
+
+// SetWorkFactor sets the base-2 logarithm of the scrypt cost N, between 1 and 30.
+// Every increment doubles the time and memory required to wrap and unwrap the file key.
+func (r *ScryptRecipient) SetWorkFactor(logN int) {
+	support.CheckIntInRange(logN, 1, 30, "work factor")
+	r.workFactor = logN
+}
+
+// Wrap wraps a file key with a key derived from the passphrase and a random salt.
+func (r *ScryptRecipient) Wrap(fileKey []byte) ([]*Stanza, error) {
+	salt := randombytes.RandomBytes(scryptSaltSize)
+
+	k, err := scrypt(r.passphrase, salt, r.workFactor)
+	if err != nil {
+		return nil, err
+	}
+	defer sodium.MemZero(k[:])
+
+	var nonce [chacha20poly1305ietf.NonceBytes]byte
+
+	return []*Stanza{{
+		Type: "scrypt",
+		Args: []string{b64.EncodeToString(salt), strconv.Itoa(r.workFactor)},
+		Body: chacha20poly1305ietf.Seal(nil, fileKey, nil, &nonce, k),
+	}}, nil
+}
+
+// ScryptIdentity unwraps file keys wrapped with a passphrase.
+type ScryptIdentity struct {
+	passphrase    []byte
+	maxWorkFactor int
+}
+
+// NewScryptIdentity returns an identity for a passphrase, accepting work factors
+// up to DefaultMaxWorkFactor.
+func NewScryptIdentity(passphrase string) (*ScryptIdentity, error) {
+	if passphrase == "" {
+		return nil, errors.New("age: empty passphrase")
+	}
+
+	return &ScryptIdentity{passphrase: []byte(passphrase), maxWorkFactor: DefaultMaxWorkFactor}, nil
+}
+
+// SetMaxWorkFactor sets the highest work factor accepted, between 1 and 30, which
+// bounds the time and memory an untrusted file can make Unwrap spend.
+func (i *ScryptIdentity) SetMaxWorkFactor(logN int) {
+	support.CheckIntInRange(logN, 1, 30, "work factor")
+	i.maxWorkFactor = logN
+}
+
+// Unwrap unwraps the file key from an scrypt stanza. It returns ErrIncorrectIdentity
+// if the stanza is of another type or was wrapped with another passphrase.
+func (i *ScryptIdentity) Unwrap(s *Stanza) ([]byte, error) {
+	if s.Type != "scrypt" {
+		return nil, ErrIncorrectIdentity
+	}
+
+	if len(s.Args) != 2 || len(s.Body) != wrappedKeySize {
+		return nil, errors.New("age: invalid scrypt stanza")
+	}
+
+	salt, err := decodeBase64(s.Args[0])
+	if err != nil || len(salt) != scryptSaltSize {
+		return nil, errors.New("age: invalid scrypt stanza")
+	}
+
+	// The work factor is a decimal number without leading zeros
+	arg := s.Args[1]
+	logN, err := strconv.Atoi(arg)
+	if err != nil || arg[0] < '1' || arg[0] > '9' || logN <= 0 {
+		return nil, errors.New("age: invalid scrypt stanza")
+	}
+	if logN > i.maxWorkFactor {
+		return nil, errors.New("age: scrypt work factor " + arg + " is too high")
+	}
+
+	k, err := scrypt(i.passphrase, salt, logN)
+	if err != nil {
+		return nil, err
+	}
+	defer sodium.MemZero(k[:])
+
+	var nonce [chacha20poly1305ietf.NonceBytes]byte
+
+	fileKey, err := chacha20poly1305ietf.Open(nil, s.Body, nil, &nonce, k)
+	if err != nil {
+		return nil, ErrIncorrectIdentity
+	}
+
+	return fileKey, nil
+}
@@ -0,0 +1,196 @@
+package age
+
+import (
+	"bufio"
+	"errors"
+	"github.com/GoKillers/libsodium-go/crypto/aead/chacha20poly1305ietf"
+	"io"
+)
+
+// The payload is encrypted with STREAM: ChaCha20-Poly1305 chunks of 64 KiB,
+// each with a nonce of an 11-byte big-endian counter and a flag for the last chunk.
+const (
+	chunkSize        = 64 << 10
+	encChunkSize     = chunkSize + chacha20poly1305ietf.ABytes
+	payloadNonceSize = 16 // Size of the nonce from which the payload key is derived
+	lastChunkFlag    = 1
+)
+
+// errPayload is returned when the payload has been modified or truncated.
+var errPayload = errors.New("age: payload authentication failed")
+
+type streamNonce [chacha20poly1305ietf.NonceBytes]byte
+
+// next increments the counter of the nonce.
+func (n *streamNonce) next() error {
+	for i := len(n) - 2; i >= 0; i-- {
+		n[i]++
+		if n[i] != 0 {
+			return nil
+		}
+	}
+
+	return errors.New("age: chunk counter overflow")
+}
+
+func payloadKey(fileKey, nonce []byte) *[chacha20poly1305ietf.KeyBytes]byte {
+	return (*[chacha20poly1305ietf.KeyBytes]byte)(hkdf(chacha20poly1305ietf.KeyBytes, fileKey, nonce, "payload"))
+}
+
+// streamWriter encrypts a payload. A chunk is only written once it is known
+// whether it is the last one, so a full chunk is held until more data or Close.
+type streamWriter struct {
+	w     io.Writer
+	k     *[chacha20poly1305ietf.KeyBytes]byte
+	nonce streamNonce
+	buf   []byte
+	enc   []byte
+	err   error
+}
+
+func newStreamWriter(w io.Writer, k *[chacha20poly1305ietf.KeyBytes]byte) *streamWriter {
+	return &streamWriter{
+		w:   w,
+		k:   k,
+		buf: make([]byte, 0, chunkSize),
+		enc: make([]byte, 0, encChunkSize),
+	}
+}
+
+func (s *streamWriter) Write(p []byte) (int, error) {
+	if s.err != nil {
+		return 0, s.err
+	}
+
+	n := len(p)
+	for len(p) > 0 {
+		if len(s.buf) == chunkSize {
+			if s.err = s.flush(false); s.err != nil {
+				return n - len(p), s.err
+			}
+		}
+
+		c := copy(s.buf[len(s.buf):chunkSize], p)
+		s.buf = s.buf[:len(s.buf)+c]
+		p = p[c:]
+	}
+
+	return n, nil
+}
+
+// Close writes the last chunk. It does not close the underlying writer.
+func (s *streamWriter) Close() error {
+	if s.err != nil {
+		return s.err
+	}
+
+	s.err = s.flush(true)
+	if s.err == nil {
+		s.err = errors.New("age: write to closed writer")
+		return nil
+	}
+
+	return s.err
+}
+
+func (s *streamWriter) flush(last bool) error {
+	if last {
+		s.nonce[len(s.nonce)-1] = lastChunkFlag
+	}
+
+	s.enc = chacha20poly1305ietf.Seal(s.enc[:0], s.buf, nil, (*[chacha20poly1305ietf.NonceBytes]byte)(&s.nonce), s.k)
+	s.buf = s.buf[:0]
+
+	if _, err := s.w.Write(s.enc); err != nil {
+		return err
+	}
+
+	return s.nonce.next()
+}
+
+// streamReader decrypts a payload. Each chunk is released once it is
+// authenticated, so on errPayload the data read before it is authentic.
+type streamReader struct {
+	r     *bufio.Reader
+	k     *[chacha20poly1305ietf.KeyBytes]byte
+	nonce streamNonce
+	buf   []byte // Decrypted data that has not been read yet
+	enc   []byte
+	dec   []byte
+	first bool
+	err   error
+}
+
+func newStreamReader(r *bufio.Reader, k *[chacha20poly1305ietf.KeyBytes]byte) *streamReader {
+	return &streamReader{
+		r:     r,
+		k:     k,
+		enc:   make([]byte, encChunkSize),
+		dec:   make([]byte, 0, chunkSize),
+		first: true,
+	}
+}
+
+func (s *streamReader) Read(p []byte) (int, error) {
+	for len(s.buf) == 0 {
+		if s.err != nil {
+			return 0, s.err
+		}
+		s.err = s.readChunk()
+	}
+
+	n := copy(p, s.buf)
+	s.buf = s.buf[n:]
+
+	return n, nil
+}
+
+// readChunk decrypts the next chunk into the buffer. It returns io.EOF after the last chunk.
+func (s *streamReader) readChunk() error {
+	n, err := io.ReadFull(s.r, s.enc)
+	last := false
+
+	switch err {
+	case nil:
+	case io.EOF:
+		// The payload ended without a last chunk
+		return errPayload
+	case io.ErrUnexpectedEOF:
+		// Only the last chunk may be short, and it may only be empty if it is the only chunk
+		if n < chacha20poly1305ietf.ABytes || (n == chacha20poly1305ietf.ABytes && !s.first) {
+			return errPayload
+		}
+
+		last = true
+		s.nonce[len(s.nonce)-1] = lastChunkFlag
+	default:
+		return err
+	}
+
+	m, err := chacha20poly1305ietf.Open(s.dec[:0], s.enc[:n], nil, (*[chacha20poly1305ietf.NonceBytes]byte)(&s.nonce), s.k)
+	if err != nil && !last {
+		// A full chunk may be the last one
+		last = true
+		s.nonce[len(s.nonce)-1] = lastChunkFlag
+		m, err = chacha20poly1305ietf.Open(s.dec[:0], s.enc[:n], nil, (*[chacha20poly1305ietf.NonceBytes]byte)(&s.nonce), s.k)
+	}
+	if err != nil {
+		return errPayload
+	}
+
+	s.buf = m
+	s.first = false
+
+	if !last {
+		return s.nonce.next()
+	}
+
+	// Nothing may follow the last chunk
+	if _, err := s.r.Peek(1); err == nil {
+		return errPayload
+	} else if err != io.EOF {
+		return err
+	}
+
+	return io.EOF
+}
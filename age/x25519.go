@@ -0,0 +1,144 @@
+package age
+
+import (
+	"errors"
+	"github.com/GoKillers/libsodium-go/crypto/aead/chacha20poly1305ietf"
+	"github.com/GoKillers/libsodium-go/randombytes"
+	"github.com/GoKillers/libsodium-go/scalarmult"
+	"github.com/GoKillers/libsodium-go/sodium"
+	"strings"
+)
+
+const (
+	x25519Bytes    = 32
+	x25519Label    = "age-encryption.org/v1/X25519"
+	recipientHRP   = "age"
+	identityHRP    = "age-secret-key-"
+	wrappedKeySize = fileKeySize + chacha20poly1305ietf.ABytes
+)
+
+// X25519Recipient is the public key of an X25519 identity.
+type X25519Recipient struct {
+	pk []byte
+}
+
+// ParseX25519Recipient parses a recipient encoded as "age1...".
+func ParseX25519Recipient(s string) (*X25519Recipient, error) {
+	hrp, pk, err := bech32Decode(s)
+	if err != nil {
+		return nil, err
+	}
+
+	if hrp != recipientHRP || len(pk) != x25519Bytes {
+		return nil, errors.New("age: invalid X25519 recipient")
+	}
+
+	return &X25519Recipient{pk: pk}, nil
+}
+
+// String returns the encoding of the recipient.
+func (r *X25519Recipient) String() string {
+	return bech32Encode(recipientHRP, r.pk)
+}
+
+// Wrap wraps a file key for the recipient with an ephemeral key exchange.
+func (r *X25519Recipient) Wrap(fileKey []byte) ([]*Stanza, error) {
+	e := randombytes.RandomBytes(x25519Bytes)
+	defer sodium.MemZero(e)
+
+	share, _ := scalarmult.CryptoScalarmultBase(e)
+
+	secret, exit := scalarmult.CryptoScalarMult(e, r.pk)
+	if exit != 0 {
+		return nil, errors.New("age: invalid X25519 recipient")
+	}
+	defer sodium.MemZero(secret)
+
+	k := x25519WrapKey(secret, share, r.pk)
+	var nonce [chacha20poly1305ietf.NonceBytes]byte
+
+	return []*Stanza{{
+		Type: "X25519",
+		Args: []string{b64.EncodeToString(share)},
+		Body: chacha20poly1305ietf.Seal(nil, fileKey, nil, &nonce, k),
+	}}, nil
+}
+
+// x25519WrapKey derives the key wrapping the file key from a shared secret.
+func x25519WrapKey(secret, share, pk []byte) *[chacha20poly1305ietf.KeyBytes]byte {
+	salt := append(append(make([]byte, 0, 2*x25519Bytes), share...), pk...)
+
+	return (*[chacha20poly1305ietf.KeyBytes]byte)(hkdf(chacha20poly1305ietf.KeyBytes, secret, salt, x25519Label))
+}
+
+// X25519Identity is an X25519 secret key, which unwraps file keys wrapped for its recipient.
+type X25519Identity struct {
+	sk, pk []byte
+}
+
+// GenerateX25519Identity generates a random identity.
+func GenerateX25519Identity() *X25519Identity {
+	sk := randombytes.RandomBytes(x25519Bytes)
+	pk, _ := scalarmult.CryptoScalarmultBase(sk)
+
+	return &X25519Identity{sk: sk, pk: pk}
+}
+
+// ParseX25519Identity parses an identity encoded as "AGE-SECRET-KEY-1...".
+func ParseX25519Identity(s string) (*X25519Identity, error) {
+	hrp, sk, err := bech32Decode(s)
+	if err != nil {
+		return nil, err
+	}
+
+	if hrp != identityHRP || len(sk) != x25519Bytes {
+		return nil, errors.New("age: invalid X25519 identity")
+	}
+
+	pk, _ := scalarmult.CryptoScalarmultBase(sk)
+
+	return &X25519Identity{sk: sk, pk: pk}, nil
+}
+
+// Recipient returns the recipient of the identity.
+func (i *X25519Identity) Recipient() *X25519Recipient {
+	return &X25519Recipient{pk: i.pk}
+}
+
+// String returns the encoding of the identity.
+func (i *X25519Identity) String() string {
+	return strings.ToUpper(bech32Encode(identityHRP, i.sk))
+}
+
+// Unwrap unwraps the file key from an X25519 stanza. It returns ErrIncorrectIdentity
+// if the stanza is of another type or was not wrapped for the identity.
+func (i *X25519Identity) Unwrap(s *Stanza) ([]byte, error) {
+	if s.Type != "X25519" {
+		return nil, ErrIncorrectIdentity
+	}
+
+	if len(s.Args) != 1 || len(s.Body) != wrappedKeySize {
+		return nil, errors.New("age: invalid X25519 stanza")
+	}
+
+	share, err := decodeBase64(s.Args[0])
+	if err != nil || len(share) != x25519Bytes {
+		return nil, errors.New("age: invalid X25519 stanza")
+	}
+
+	secret, exit := scalarmult.CryptoScalarMult(i.sk, share)
+	if exit != 0 {
+		return nil, errors.New("age: invalid X25519 stanza")
+	}
+	defer sodium.MemZero(secret)
+
+	k := x25519WrapKey(secret, share, i.pk)
+	var nonce [chacha20poly1305ietf.NonceBytes]byte
+
+	fileKey, err := chacha20poly1305ietf.Open(nil, s.Body, nil, &nonce, k)
+	if err != nil {
+		return nil, ErrIncorrectIdentity
+	}
+
+	return fileKey, nil
+}
@@ -2,6 +2,7 @@ package aes256gcm
 
 import (
 	"bytes"
+	"github.com/GoKillers/libsodium-go/crypto/aead/internal/sealopentest"
 	"github.com/google/gofuzz"
 	"testing"
 )
@@ -85,3 +86,43 @@ func Test(t *testing.T) {
 	}
 	t.Logf("Completed %v tests", testCount)
 }
+
+// testCipher adapts the package to the shared Seal and Open tests.
+var testCipher = &sealopentest.Cipher{
+	KeyBytes:   KeyBytes,
+	NonceBytes: NonceBytes,
+	ABytes:     ABytes,
+	Seal: func(dst, m, ad, nonce, k []byte) []byte {
+		return Seal(dst, m, ad, (*[NonceBytes]byte)(nonce), (*[KeyBytes]byte)(k))
+	},
+	Open: func(dst, c, ad, nonce, k []byte) ([]byte, error) {
+		return Open(dst, c, ad, (*[NonceBytes]byte)(nonce), (*[KeyBytes]byte)(k))
+	},
+	Encrypt: func(m, ad, nonce, k []byte) []byte {
+		return Encrypt(m, ad, (*[NonceBytes]byte)(nonce), (*[KeyBytes]byte)(k))
+	},
+}
+
+func TestSealOpen(t *testing.T) {
+	if !IsAvailable() {
+		t.Skip("The CPU does not support this implementation of AES256GCM.")
+	}
+
+	sealopentest.Test(t, testCipher)
+}
+
+func BenchmarkSeal(b *testing.B) {
+	if !IsAvailable() {
+		b.Skip("The CPU does not support this implementation of AES256GCM.")
+	}
+
+	sealopentest.BenchmarkSeal(b, testCipher)
+}
+
+func BenchmarkOpen(b *testing.B) {
+	if !IsAvailable() {
+		b.Skip("The CPU does not support this implementation of AES256GCM.")
+	}
+
+	sealopentest.BenchmarkOpen(b, testCipher)
+}
@@ -5,11 +5,13 @@ package chacha20poly1305
 // #include <stdlib.h>
 // #include <sodium.h>
 import "C"
+import "github.com/GoKillers/libsodium-go/crypto/aead/internal/sealopen"
+import "github.com/GoKillers/libsodium-go/sodium"
 import "github.com/GoKillers/libsodium-go/support"
 
 // Sodium should always be initialised
 func init() {
-	C.sodium_init()
+	sodium.Initialize()
 }
 
 // Sizes of nonces, key and mac.
@@ -128,3 +130,60 @@ func DecryptDetached(c, mac, ad []byte, nonce *[NonceBytes]byte, k *[KeyBytes]by
 
 	return
 }
+
+// sealOpen calls the C functions for Seal and Open.
+var sealOpen = sealopen.Cipher{
+	ABytes: ABytes,
+	Encrypt: func(c, m, ad, nonce, k []byte) {
+		C.crypto_aead_chacha20poly1305_encrypt(
+			(*C.uchar)(&c[0]),
+			(*C.ulonglong)(nil),
+			(*C.uchar)(support.BytePointer(m)),
+			(C.ulonglong)(len(m)),
+			(*C.uchar)(support.BytePointer(ad)),
+			(C.ulonglong)(len(ad)),
+			(*C.uchar)(nil),
+			(*C.uchar)(&nonce[0]),
+			(*C.uchar)(&k[0]))
+	},
+	Decrypt: func(m, c, ad, nonce, k []byte) int {
+		return int(C.crypto_aead_chacha20poly1305_decrypt(
+			(*C.uchar)(support.BytePointer(m)),
+			(*C.ulonglong)(nil),
+			(*C.uchar)(nil),
+			(*C.uchar)(&c[0]),
+			(C.ulonglong)(len(c)),
+			(*C.uchar)(support.BytePointer(ad)),
+			(C.ulonglong)(len(ad)),
+			(*C.uchar)(&nonce[0]),
+			(*C.uchar)(&k[0])))
+	},
+}
+
+// Seal encrypts a message `m` with additional data `ad` using a nonce and a secret key `k`,
+// and appends the ciphertext (including authentication tag) to `dst`, returning the updated slice.
+// No memory is allocated if `dst` has enough capacity.
+//
+// The message and dst may alias exactly or not at all. To reuse
+// the storage of the message for the ciphertext, use m[:0] as dst.
+func Seal(dst, m, ad []byte, nonce *[NonceBytes]byte, k *[KeyBytes]byte) []byte {
+	support.NilPanic(k == nil, "secret key")
+	support.NilPanic(nonce == nil, "nonce")
+
+	return sealOpen.Seal(dst, m, ad, nonce[:], k[:])
+}
+
+// Open decrypts and verifies a ciphertext `c` using additional data `ad`, a nonce and a secret key `k`,
+// and appends the message to `dst`, returning the updated slice.
+// No memory is allocated if `dst` has enough capacity.
+//
+// The ciphertext and dst may alias exactly or not at all. To reuse
+// the storage of the ciphertext for the message, use c[:0] as dst.
+// Even if the function fails, the contents of dst, up to its capacity,
+// may be overwritten.
+func Open(dst, c, ad []byte, nonce *[NonceBytes]byte, k *[KeyBytes]byte) ([]byte, error) {
+	support.NilPanic(k == nil, "secret key")
+	support.NilPanic(nonce == nil, "nonce")
+
+	return sealOpen.Open(dst, c, ad, nonce[:], k[:])
+}
@@ -2,6 +2,7 @@ package chacha20poly1305ietf
 
 import (
 	"bytes"
+	"github.com/GoKillers/libsodium-go/crypto/aead/internal/sealopentest"
 	"github.com/google/gofuzz"
 	"testing"
 )
@@ -80,3 +81,23 @@ func Test(t *testing.T) {
 	}
 	t.Logf("Completed %v tests", testCount)
 }
+
+// testCipher adapts the package to the shared Seal and Open tests.
+var testCipher = &sealopentest.Cipher{
+	KeyBytes:   KeyBytes,
+	NonceBytes: NonceBytes,
+	ABytes:     ABytes,
+	Seal: func(dst, m, ad, nonce, k []byte) []byte {
+		return Seal(dst, m, ad, (*[NonceBytes]byte)(nonce), (*[KeyBytes]byte)(k))
+	},
+	Open: func(dst, c, ad, nonce, k []byte) ([]byte, error) {
+		return Open(dst, c, ad, (*[NonceBytes]byte)(nonce), (*[KeyBytes]byte)(k))
+	},
+	Encrypt: func(m, ad, nonce, k []byte) []byte {
+		return Encrypt(m, ad, (*[NonceBytes]byte)(nonce), (*[KeyBytes]byte)(k))
+	},
+}
+
+func TestSealOpen(t *testing.T)  { sealopentest.Test(t, testCipher) }
+func BenchmarkSeal(b *testing.B) { sealopentest.BenchmarkSeal(b, testCipher) }
+func BenchmarkOpen(b *testing.B) { sealopentest.BenchmarkOpen(b, testCipher) }
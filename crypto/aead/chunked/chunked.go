@@ -0,0 +1,196 @@
+// Package chunked implements parallel encryption of large files.
+//
+// The input is split into chunks that are encrypted independently, each with a
+// nonce derived from a random base nonce and the index of the chunk, so that
+// chunks can be processed by a pool of goroutines and decrypted in any order.
+//
+// An encrypted file consists of a header followed by the encrypted chunks.
+// Every chunk but the last holds ChunkSize bytes of plaintext, the last one
+// holds the remainder, which may be empty. The header, the index of a chunk and
+// whether it is the last one are authenticated as additional data, so chunks can
+// not be reordered, dropped or moved between files, and truncation is detected.
+package chunked
+
+import (
+	"encoding/binary"
+	"errors"
+	"github.com/GoKillers/libsodium-go/crypto/aead/aes256gcm"
+	"github.com/GoKillers/libsodium-go/crypto/aead/xchacha20poly1305ietf"
+	"github.com/GoKillers/libsodium-go/sodium"
+	"runtime"
+)
+
+// Algorithm identifies the AEAD used to encrypt the chunks.
+type Algorithm byte
+
+// Supported algorithms.
+const (
+	XChaCha20Poly1305IETF Algorithm = iota + 1 // XChaCha20-Poly1305-IETF with a 192-bit nonce
+	AES256GCM                                  // AES256-GCM with a 96-bit nonce, requires hardware support
+)
+
+// Sizes and limits.
+const (
+	KeyBytes         = xchacha20poly1305ietf.KeyBytes // Size of a secret key in bytes
+	DefaultChunkSize = 64 * 1024                      // Default size of the plaintext of a chunk
+	MaxChunkSize     = 1 << 24                        // Maximum size of the plaintext of a chunk
+)
+
+// magic identifies the format and its version at the start of the header.
+const magic = "LSCF\x01"
+
+// headerPrefixSize is the size of the header without the base nonce.
+const headerPrefixSize = len(magic) + 1 + 4
+
+// Config configures the encryption of a file. The zero value selects the defaults.
+type Config struct {
+	Algorithm Algorithm // Cipher to use, XChaCha20Poly1305IETF if zero
+	ChunkSize int       // Size of the plaintext of a chunk, DefaultChunkSize if zero
+	Workers   int       // Number of goroutines, runtime.GOMAXPROCS(0) if zero
+}
+
+// withDefaults returns a copy of the configuration with the defaults filled in,
+// or an error if the configuration is invalid.
+func (c *Config) withDefaults() (Config, error) {
+	var cfg Config
+	if c != nil {
+		cfg = *c
+	}
+
+	if cfg.Algorithm == 0 {
+		cfg.Algorithm = XChaCha20Poly1305IETF
+	}
+
+	if cfg.ChunkSize == 0 {
+		cfg.ChunkSize = DefaultChunkSize
+	}
+
+	if cfg.ChunkSize < 1 || cfg.ChunkSize > MaxChunkSize {
+		return cfg, errors.New("chunked: invalid chunk size")
+	}
+
+	cfg.Workers = workers(cfg.Workers)
+
+	return cfg, nil
+}
+
+// workers returns the number of goroutines to use for a configured value.
+func workers(n int) int {
+	if n <= 0 {
+		return runtime.GOMAXPROCS(0)
+	}
+	return n
+}
+
+// chunkCipher seals and opens chunks with an AEAD.
+type chunkCipher struct {
+	nonceSize int
+	overhead  int
+	seal      func(dst, m, ad, nonce []byte) []byte
+	open      func(dst, c, ad, nonce []byte) ([]byte, error)
+	key       *[KeyBytes]byte // Copy of the key used by seal and open
+}
+
+// wipe zeroes the copy of the key. The cipher can not be used afterwards.
+func (c *chunkCipher) wipe() {
+	sodium.MemZero(c.key[:])
+}
+
+// newChunkCipher returns a chunkCipher for an algorithm, using a copy of the key.
+func newChunkCipher(alg Algorithm, key *[KeyBytes]byte) (*chunkCipher, error) {
+	k := *key
+
+	switch alg {
+	case XChaCha20Poly1305IETF:
+		return &chunkCipher{
+			key:       &k,
+			nonceSize: xchacha20poly1305ietf.NonceBytes,
+			overhead:  xchacha20poly1305ietf.ABytes,
+			seal: func(dst, m, ad, nonce []byte) []byte {
+				return xchacha20poly1305ietf.Seal(dst, m, ad, (*[xchacha20poly1305ietf.NonceBytes]byte)(nonce), &k)
+			},
+			open: func(dst, c, ad, nonce []byte) ([]byte, error) {
+				return xchacha20poly1305ietf.Open(dst, c, ad, (*[xchacha20poly1305ietf.NonceBytes]byte)(nonce), &k)
+			},
+		}, nil
+	case AES256GCM:
+		if !aes256gcm.IsAvailable() {
+			return nil, errors.New("chunked: AES256-GCM is not available on this CPU")
+		}
+
+		ak := (*[aes256gcm.KeyBytes]byte)(&k)
+		return &chunkCipher{
+			key:       &k,
+			nonceSize: aes256gcm.NonceBytes,
+			overhead:  aes256gcm.ABytes,
+			seal: func(dst, m, ad, nonce []byte) []byte {
+				return aes256gcm.Seal(dst, m, ad, (*[aes256gcm.NonceBytes]byte)(nonce), ak)
+			},
+			open: func(dst, c, ad, nonce []byte) ([]byte, error) {
+				return aes256gcm.Open(dst, c, ad, (*[aes256gcm.NonceBytes]byte)(nonce), ak)
+			},
+		}, nil
+	default:
+		sodium.MemZero(k[:])
+		return nil, errors.New("chunked: unknown algorithm")
+	}
+}
+
+// header is the header of an encrypted file.
+type header struct {
+	alg       Algorithm
+	chunkSize int
+	nonce     []byte // Base nonce
+	raw       []byte // Encoded header, authenticated with every chunk
+}
+
+// newHeader returns a header and its encoding.
+func newHeader(alg Algorithm, chunkSize int, nonce []byte) *header {
+	raw := make([]byte, headerPrefixSize, headerPrefixSize+len(nonce))
+	copy(raw, magic)
+	raw[len(magic)] = byte(alg)
+	binary.LittleEndian.PutUint32(raw[len(magic)+1:], uint32(chunkSize))
+	raw = append(raw, nonce...)
+
+	return &header{alg: alg, chunkSize: chunkSize, nonce: raw[headerPrefixSize:], raw: raw}
+}
+
+// parseHeaderPrefix decodes the algorithm and chunk size from the start of a header.
+func parseHeaderPrefix(b []byte) (Algorithm, int, error) {
+	if string(b[:len(magic)]) != magic {
+		return 0, 0, errors.New("chunked: not an encrypted file")
+	}
+
+	chunkSize := int(binary.LittleEndian.Uint32(b[len(magic)+1:]))
+	if chunkSize < 1 || chunkSize > MaxChunkSize {
+		return 0, 0, errors.New("chunked: invalid chunk size")
+	}
+
+	return Algorithm(b[len(magic)]), chunkSize, nil
+}
+
+// chunkNonce writes the nonce of chunk `index` to `nonce`: the base nonce
+// with the little-endian index XORed into its first 8 bytes.
+func (h *header) chunkNonce(nonce []byte, index uint64) []byte {
+	nonce = append(nonce[:0], h.nonce...)
+
+	var ctr [8]byte
+	binary.LittleEndian.PutUint64(ctr[:], index)
+	for i := range ctr {
+		nonce[i] ^= ctr[i]
+	}
+
+	return nonce
+}
+
+// chunkAD writes the additional data of chunk `index` to `ad`:
+// the header, the little-endian index and a flag marking the last chunk.
+func (h *header) chunkAD(ad []byte, index uint64, final bool) []byte {
+	ad = append(ad[:0], h.raw...)
+	ad = binary.LittleEndian.AppendUint64(ad, index)
+
+	if final {
+		return append(ad, 1)
+	}
+	return append(ad, 0)
+}
@@ -0,0 +1,184 @@
+package chunked
+
+import (
+	"bytes"
+	"github.com/GoKillers/libsodium-go/crypto/aead/aes256gcm"
+	"github.com/GoKillers/libsodium-go/randombytes"
+	"github.com/GoKillers/libsodium-go/sodium"
+	"io"
+	"strconv"
+	"testing"
+)
+
+var testAlgorithms = []struct {
+	name string
+	alg  Algorithm
+}{
+	{"XChaCha20Poly1305IETF", XChaCha20Poly1305IETF},
+	{"AES256GCM", AES256GCM},
+}
+
+func encrypt(t *testing.T, m []byte, k *[KeyBytes]byte, config *Config) []byte {
+	var buf bytes.Buffer
+	if err := Encrypt(&buf, bytes.NewReader(m), k, config); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestRoundTrip(t *testing.T) {
+	k := new([KeyBytes]byte)
+	randombytes.RandomBytesBuf(k[:])
+
+	for _, test := range testAlgorithms {
+		if test.alg == AES256GCM && !aes256gcm.IsAvailable() {
+			continue
+		}
+
+		for _, size := range []int{0, 1, 99, 100, 101, 1000, 12345} {
+			m := randombytes.RandomBytes(size)
+
+			for _, workers := range []int{1, 4} {
+				c := encrypt(t, m, k, &Config{Algorithm: test.alg, ChunkSize: 100, Workers: workers})
+
+				d, err := NewDecryptor(bytes.NewReader(c), int64(len(c)), k)
+				if err != nil {
+					t.Fatalf("%s/%d: %v", test.name, size, err)
+				}
+
+				if d.Size() != int64(size) || d.Algorithm() != test.alg {
+					t.Fatalf("%s/%d: wrong size %d or algorithm", test.name, size, d.Size())
+				}
+
+				d.Workers = workers
+				var out bytes.Buffer
+				if _, err := d.WriteTo(&out); err != nil || !bytes.Equal(out.Bytes(), m) {
+					t.Fatalf("%s/%d: WriteTo failed: %v", test.name, size, err)
+				}
+			}
+		}
+	}
+}
+
+func TestRandomAccess(t *testing.T) {
+	k := (*[KeyBytes]byte)(randombytes.RandomBytes(KeyBytes))
+	m := randombytes.RandomBytes(1000)
+	c := encrypt(t, m, k, &Config{ChunkSize: 64})
+
+	d, err := NewDecryptor(bytes.NewReader(c), int64(len(c)), k)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if d.Chunks() != 16 {
+		t.Fatalf("Expected 16 chunks, got %d", d.Chunks())
+	}
+
+	chunk, err := d.Chunk(nil, 15)
+	if err != nil || !bytes.Equal(chunk, m[960:]) {
+		t.Fatalf("Chunk failed: %v", err)
+	}
+
+	p := make([]byte, 200)
+	if n, err := d.ReadAt(p, 100); n != len(p) || err != nil || !bytes.Equal(p, m[100:300]) {
+		t.Fatalf("ReadAt failed: %v", err)
+	}
+
+	if n, err := d.ReadAt(p, 900); n != 100 || err != io.EOF || !bytes.Equal(p[:n], m[900:]) {
+		t.Fatalf("ReadAt at the end failed: %d, %v", n, err)
+	}
+
+	// Close wipes the copy of the key, not the key
+	d.Close()
+	if _, err := d.Chunk(nil, 0); err == nil {
+		t.Fatal("Chunk decrypted after Close")
+	}
+	if sodium.IsZero(k[:]) {
+		t.Fatal("Key wiped by Close")
+	}
+}
+
+func TestTampering(t *testing.T) {
+	k := new([KeyBytes]byte)
+	m := randombytes.RandomBytes(1000)
+	c := encrypt(t, m, k, &Config{ChunkSize: 100})
+	full := 100 + 16
+	header := len(c) - 10*full
+
+	decrypt := func(c []byte) error {
+		d, err := NewDecryptor(bytes.NewReader(c), int64(len(c)), k)
+		if err != nil {
+			return err
+		}
+
+		_, err = d.WriteTo(io.Discard)
+		return err
+	}
+
+	if err := decrypt(c); err != nil {
+		t.Fatal(err)
+	}
+
+	// Modified chunk
+	forged := append([]byte(nil), c...)
+	forged[header+5*full] ^= 1
+	if decrypt(forged) == nil {
+		t.Error("Modified chunk not detected")
+	}
+
+	// Swapped chunks
+	forged = append([]byte(nil), c...)
+	copy(forged[header:], c[header+full:header+2*full])
+	copy(forged[header+full:], c[header:header+full])
+	if decrypt(forged) == nil {
+		t.Error("Reordered chunks not detected")
+	}
+
+	// Truncation at a chunk boundary
+	if decrypt(c[:len(c)-full]) == nil {
+		t.Error("Truncation not detected")
+	}
+
+	// Modified header
+	forged = append([]byte(nil), c...)
+	forged[header-1] ^= 1
+	if decrypt(forged) == nil {
+		t.Error("Modified header not detected")
+	}
+
+	// Wrong key
+	k[0] ^= 1
+	d, err := NewDecryptor(bytes.NewReader(c), int64(len(c)), k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := d.Chunk(nil, 0); err == nil {
+		t.Error("Wrong key not detected")
+	}
+}
+
+func TestJobRelease(t *testing.T) {
+	j := newJob(0)
+	j.in = append(j.in[:0], "ciphertext"...)
+	j.out = append(j.out[:0], "plaintext"...)
+	in, out := j.in[:cap(j.in)], j.out[:cap(j.out)]
+
+	j.release()
+	if !sodium.IsZero(in) || !sodium.IsZero(out) {
+		t.Fatal("Released job not zeroed")
+	}
+}
+
+func BenchmarkEncrypt(b *testing.B) {
+	k := new([KeyBytes]byte)
+	m := make([]byte, 16<<20)
+
+	for _, workers := range []int{1, 4} {
+		b.Run("Workers"+strconv.Itoa(workers), func(b *testing.B) {
+			b.SetBytes(int64(len(m)))
+			for i := 0; i < b.N; i++ {
+				Encrypt(io.Discard, bytes.NewReader(m), k, &Config{Workers: workers})
+			}
+		})
+	}
+}
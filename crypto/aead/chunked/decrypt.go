@@ -0,0 +1,230 @@
+package chunked
+
+import (
+	"errors"
+	"github.com/GoKillers/libsodium-go/support"
+	"io"
+)
+
+// Decryptor decrypts a file encrypted by Encrypt, either sequentially with
+// WriteTo or with random access to any chunk with Chunk and ReadAt.
+// A Decryptor is safe for concurrent use if its underlying io.ReaderAt is.
+type Decryptor struct {
+	// Workers is the number of goroutines used by WriteTo,
+	// runtime.GOMAXPROCS(0) if zero.
+	Workers int
+
+	r      io.ReaderAt
+	h      *header
+	c      *chunkCipher
+	chunks uint64 // Number of chunks
+	last   int    // Size of the last encrypted chunk
+	size   int64  // Size of the plaintext
+}
+
+// NewDecryptor returns a Decryptor for an encrypted file of `size` bytes
+// read from `r`, using a secret key `k`. The header of the file is checked,
+// but no chunk is decrypted until it is read.
+func NewDecryptor(r io.ReaderAt, size int64, k *[KeyBytes]byte) (*Decryptor, error) {
+	support.NilPanic(k == nil, "secret key")
+
+	prefix := make([]byte, headerPrefixSize)
+	if err := readFullAt(r, prefix, 0); err != nil {
+		return nil, err
+	}
+
+	alg, chunkSize, err := parseHeaderPrefix(prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := newChunkCipher(alg, k)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, c.nonceSize)
+	if err := readFullAt(r, nonce, int64(headerPrefixSize)); err != nil {
+		c.wipe()
+		return nil, err
+	}
+
+	d := &Decryptor{r: r, h: newHeader(alg, chunkSize, nonce), c: c}
+
+	// Every file has at least one chunk, of which only the last may be short
+	payload := size - int64(len(d.h.raw))
+	full := int64(chunkSize + c.overhead)
+
+	if payload < int64(c.overhead) {
+		c.wipe()
+		return nil, errors.New("chunked: truncated file")
+	}
+
+	d.chunks = uint64(payload / full)
+	d.last = int(payload % full)
+
+	if d.last == 0 {
+		d.last = int(full)
+	} else if d.last < c.overhead {
+		c.wipe()
+		return nil, errors.New("chunked: truncated chunk")
+	} else {
+		d.chunks++
+	}
+
+	d.size = payload - int64(d.chunks)*int64(c.overhead)
+
+	return d, nil
+}
+
+// readFullAt reads exactly len(b) bytes at offset `off`, returning
+// io.ErrUnexpectedEOF if the end of the data is reached first.
+func readFullAt(r io.ReaderAt, b []byte, off int64) error {
+	n, err := r.ReadAt(b, off)
+	if n == len(b) {
+		return nil
+	}
+
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// Algorithm returns the algorithm used to encrypt the file.
+func (d *Decryptor) Algorithm() Algorithm {
+	return d.h.alg
+}
+
+// ChunkSize returns the size of the plaintext of all chunks but the last.
+func (d *Decryptor) ChunkSize() int {
+	return d.h.chunkSize
+}
+
+// Chunks returns the number of chunks in the file.
+func (d *Decryptor) Chunks() uint64 {
+	return d.chunks
+}
+
+// Size returns the size of the decrypted file.
+func (d *Decryptor) Size() int64 {
+	return d.size
+}
+
+// Close zeroes the copy of the key held by the Decryptor, which can not be used afterwards.
+func (d *Decryptor) Close() error {
+	d.c.wipe()
+	return nil
+}
+
+// Chunk reads and decrypts chunk `index` and appends its plaintext to `dst`,
+// returning the updated slice. A VerificationError is returned if the chunk
+// has been modified.
+func (d *Decryptor) Chunk(dst []byte, index uint64) ([]byte, error) {
+	j := newJob(index)
+	defer j.release()
+
+	if err := d.decrypt(j); err != nil {
+		return nil, err
+	}
+
+	return append(dst, j.out...), nil
+}
+
+// decrypt reads and decrypts the chunk of a job into its output.
+func (d *Decryptor) decrypt(j *job) error {
+	if j.index >= d.chunks {
+		return errors.New("chunked: chunk index out of range")
+	}
+
+	full := d.h.chunkSize + d.c.overhead
+	j.final = j.index == d.chunks-1
+
+	n := full
+	if j.final {
+		n = d.last
+	}
+
+	if cap(j.in) < n {
+		j.in = make([]byte, full)
+	}
+	j.in = j.in[:n]
+
+	off := int64(len(d.h.raw)) + int64(j.index)*int64(full)
+	if err := readFullAt(d.r, j.in, off); err != nil {
+		return err
+	}
+
+	j.nonce = d.h.chunkNonce(j.nonce, j.index)
+	j.ad = d.h.chunkAD(j.ad, j.index, j.final)
+
+	out, err := d.c.open(j.out[:0], j.in, j.ad, j.nonce)
+	if err != nil {
+		return err
+	}
+
+	j.out = out
+
+	return nil
+}
+
+// ReadAt reads and decrypts len(p) bytes of the plaintext starting at
+// offset `off`, decrypting only the chunks that contain them.
+// See io.ReaderAt for details.
+func (d *Decryptor) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, errors.New("chunked: negative offset")
+	}
+
+	j := newJob(0)
+	defer j.release()
+
+	var n int
+
+	for n < len(p) {
+		if off >= d.size {
+			return n, io.EOF
+		}
+
+		j.index = uint64(off / int64(d.h.chunkSize))
+		if err := d.decrypt(j); err != nil {
+			return n, err
+		}
+
+		m := copy(p[n:], j.out[off%int64(d.h.chunkSize):])
+		n += m
+		off += int64(m)
+	}
+
+	return n, nil
+}
+
+// WriteTo decrypts the whole file on d.Workers goroutines and writes
+// the plaintext to `w` in order. It stops at the first chunk that can not
+// be decrypted, after writing the plaintext of all chunks before it.
+func (d *Decryptor) WriteTo(w io.Writer) (int64, error) {
+	var written int64
+
+	produce := func(emit func(*job) bool) error {
+		for index := uint64(0); index < d.chunks; index++ {
+			if !emit(newJob(index)) {
+				break
+			}
+		}
+		return nil
+	}
+
+	process := func(j *job) {
+		j.err = d.decrypt(j)
+	}
+
+	consume := func(j *job) error {
+		n, err := w.Write(j.out)
+		written += int64(n)
+		return err
+	}
+
+	err := runPipeline(workers(d.Workers), produce, process, consume)
+
+	return written, err
+}
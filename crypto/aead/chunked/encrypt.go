@@ -0,0 +1,82 @@
+package chunked
+
+import (
+	"bufio"
+	"github.com/GoKillers/libsodium-go/randombytes"
+	"github.com/GoKillers/libsodium-go/support"
+	"io"
+)
+
+// Encrypt reads a file from `src`, encrypts it with a secret key `k` and writes
+// the result to `dst`. The chunks are encrypted in parallel on config.Workers
+// goroutines and written in order. A nil config selects the defaults.
+//
+// A random base nonce is generated for every file, so a key can be used to
+// encrypt many files. With AES256GCM, whose base nonce is only 96 bits long,
+// a key should not be used for more than 2^32 files.
+func Encrypt(dst io.Writer, src io.Reader, k *[KeyBytes]byte, config *Config) error {
+	support.NilPanic(k == nil, "secret key")
+
+	cfg, err := config.withDefaults()
+	if err != nil {
+		return err
+	}
+
+	c, err := newChunkCipher(cfg.Algorithm, k)
+	if err != nil {
+		return err
+	}
+	defer c.wipe()
+
+	h := newHeader(cfg.Algorithm, cfg.ChunkSize, randombytes.RandomBytes(c.nonceSize))
+	if _, err := dst.Write(h.raw); err != nil {
+		return err
+	}
+
+	r := bufio.NewReader(src)
+
+	produce := func(emit func(*job) bool) error {
+		for index := uint64(0); ; index++ {
+			j := newJob(index)
+			if cap(j.in) < cfg.ChunkSize {
+				j.in = make([]byte, cfg.ChunkSize)
+			}
+
+			n, err := io.ReadFull(r, j.in[:cfg.ChunkSize])
+			j.in = j.in[:n]
+
+			switch err {
+			case nil:
+				// A full chunk is the last one if nothing follows it
+				if _, err := r.Peek(1); err == io.EOF {
+					j.final = true
+				} else if err != nil {
+					j.release()
+					return err
+				}
+			case io.EOF, io.ErrUnexpectedEOF:
+				j.final = true
+			default:
+				j.release()
+				return err
+			}
+
+			if !emit(j) || j.final {
+				return nil
+			}
+		}
+	}
+
+	process := func(j *job) {
+		j.nonce = h.chunkNonce(j.nonce, j.index)
+		j.ad = h.chunkAD(j.ad, j.index, j.final)
+		j.out = c.seal(j.out[:0], j.in, j.ad, j.nonce)
+	}
+
+	consume := func(j *job) error {
+		_, err := dst.Write(j.out)
+		return err
+	}
+
+	return runPipeline(cfg.Workers, produce, process, consume)
+}
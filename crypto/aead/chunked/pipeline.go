@@ -0,0 +1,115 @@
+package chunked
+
+import (
+	"github.com/GoKillers/libsodium-go/sodium"
+	"sync"
+)
+
+// job is a chunk processed by a pipeline.
+type job struct {
+	index uint64
+	final bool
+	in    []byte // Input of the chunk
+	out   []byte // Output of the chunk
+	nonce []byte // Scratch space for the nonce
+	ad    []byte // Scratch space for the additional data
+	err   error
+	done  chan struct{}
+}
+
+var jobPool = sync.Pool{
+	New: func() interface{} { return new(job) },
+}
+
+// newJob returns a job for chunk `index`, reusing the buffers of a previous job.
+func newJob(index uint64) *job {
+	j := jobPool.Get().(*job)
+	j.index, j.final, j.err = index, false, nil
+	j.done = make(chan struct{})
+	return j
+}
+
+// release zeroes the buffers of a job, which hold plaintext, and returns it to the pool.
+func (j *job) release() {
+	sodium.MemZero(j.in[:cap(j.in)])
+	sodium.MemZero(j.out[:cap(j.out)])
+	jobPool.Put(j)
+}
+
+// runPipeline processes chunks on a pool of goroutines. The `produce` function
+// generates jobs in order and passes them to `emit`, which returns false once
+// the pipeline is stopping. Each job is processed by `process` on one of
+// `workers` goroutines, and then passed to `consume` in the order they were
+// emitted. The pipeline stops at the first error returned by `produce` or
+// `consume`, or set in a job by `process`, and returns that error.
+func runPipeline(workers int, produce func(emit func(*job) bool) error, process func(*job), consume func(*job) error) error {
+	work := make(chan *job)
+	queue := make(chan *job, workers)
+	stop := make(chan struct{})
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range work {
+				process(j)
+				close(j.done)
+			}
+		}()
+	}
+
+	produced := make(chan error, 1)
+	go func() {
+		defer close(work)
+		defer close(queue)
+
+		produced <- produce(func(j *job) bool {
+			select {
+			case queue <- j:
+			case <-stop:
+				j.release()
+				return false
+			}
+
+			select {
+			case work <- j:
+				return true
+			case <-stop:
+				return false
+			}
+		})
+	}()
+
+	// Consume the jobs in order, and drain the queue after an error
+	var err error
+	var drained []*job
+	for j := range queue {
+		if err != nil {
+			drained = append(drained, j)
+			continue
+		}
+
+		<-j.done
+		if err = j.err; err == nil {
+			err = consume(j)
+		}
+		j.release()
+
+		if err != nil {
+			close(stop)
+		}
+	}
+
+	// Drained jobs may be processed until the workers stop
+	wg.Wait()
+	for _, j := range drained {
+		j.release()
+	}
+
+	if perr := <-produced; err == nil {
+		err = perr
+	}
+
+	return err
+}
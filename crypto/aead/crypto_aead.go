@@ -1,15 +1,15 @@
 // Package aead contains bindings for authenticated encryption with additional data.
 package aead
 
-// #cgo pkg-config: libsodium
-// #include <stdlib.h>
-// #include <sodium.h>
-import "C"
-import "crypto/cipher"
+import (
+	"crypto/cipher"
+	"github.com/GoKillers/libsodium-go/sodium"
+	"github.com/GoKillers/libsodium-go/support"
+)
 
 // Sodium should always be initialised
 func init() {
-	C.sodium_init()
+	sodium.Initialize()
 }
 
 // AEAD is and extended version of cipher.AEAD
@@ -40,9 +40,9 @@ type AEAD interface {
 	OpenDetached(dst, nonce, ciphertext, mac, additionalData []byte) ([]byte, error)
 }
 
-// appendSlices appends a slice with a number of empty bytes and
-// returns the new slice and a slice pointing to the empty data.
+// appendSlices appends a slice with a number of bytes and
+// returns the new slice and a slice pointing to the appended data.
+// The storage of the slice is reused if its capacity allows.
 func appendSlices(in []byte, n int) ([]byte, []byte) {
-	slice := append(in, make([]byte, n)...)
-	return slice, slice[len(in):]
+	return support.SliceForAppend(in, n)
 }
@@ -6,6 +6,7 @@ package aead
 import "C"
 import (
 	"github.com/GoKillers/libsodium-go/crypto/aead/aes256gcm"
+	"github.com/GoKillers/libsodium-go/sodium"
 	"github.com/GoKillers/libsodium-go/support"
 	"unsafe"
 )
@@ -31,6 +32,11 @@ func NewAES256GCM(k *[aes256gcm.KeyBytes]byte) AEAD {
 	return ctx
 }
 
+// Wipe zeroes the state, which is derived from the key. The cipher can not be used afterwards.
+func (a *AES256GCM) Wipe() {
+	sodium.MemZero(a.state1[:])
+}
+
 // state returns a pointer to the space allocated for the state
 func (a *AES256GCM) state() *C.crypto_aead_aes256gcm_state {
 	var offset uintptr
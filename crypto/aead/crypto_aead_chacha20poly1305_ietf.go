@@ -0,0 +1,135 @@
+package aead
+
+// #cgo pkg-config: libsodium
+// #include <stdlib.h>
+// #include <sodium.h>
+import "C"
+import (
+	"github.com/GoKillers/libsodium-go/crypto/aead/chacha20poly1305ietf"
+	"github.com/GoKillers/libsodium-go/sodium"
+	"github.com/GoKillers/libsodium-go/support"
+)
+
+// ChaCha20Poly1305IETF key struct. Its 96-bit nonces are only large enough
+// to be generated randomly for up to 2^32 messages per key.
+type ChaCha20Poly1305IETF struct {
+	key [chacha20poly1305ietf.KeyBytes]byte
+}
+
+// NewChaCha20Poly1305IETF returns a ChaCha20Poly1305IETF cipher for a key.
+func NewChaCha20Poly1305IETF(k *[chacha20poly1305ietf.KeyBytes]byte) AEAD {
+	support.NilPanic(k == nil, "key")
+
+	return &ChaCha20Poly1305IETF{key: *k}
+}
+
+// Wipe zeroes the copy of the key. The cipher can not be used afterwards.
+func (a *ChaCha20Poly1305IETF) Wipe() {
+	sodium.MemZero(a.key[:])
+}
+
+// NonceSize returns the size of the nonce for Seal() and Open()
+func (a *ChaCha20Poly1305IETF) NonceSize() int {
+	return chacha20poly1305ietf.NonceBytes
+}
+
+// Overhead returns the size of the MAC overhead for Seal() and Open()
+func (a *ChaCha20Poly1305IETF) Overhead() int {
+	return chacha20poly1305ietf.ABytes
+}
+
+// Seal encrypts plaintext using nonce and additional data and appends it to a destination.
+// See aead.AEAD for details.
+func (a *ChaCha20Poly1305IETF) Seal(dst, nonce, plaintext, additionalData []byte) (ret []byte) {
+	support.CheckSize(nonce, a.NonceSize(), "nonce")
+
+	ret, c := appendSlices(dst, len(plaintext)+a.Overhead())
+
+	C.crypto_aead_chacha20poly1305_ietf_encrypt(
+		(*C.uchar)(&c[0]),
+		(*C.ulonglong)(nil),
+		(*C.uchar)(support.BytePointer(plaintext)),
+		(C.ulonglong)(len(plaintext)),
+		(*C.uchar)(support.BytePointer(additionalData)),
+		(C.ulonglong)(len(additionalData)),
+		(*C.uchar)(nil),
+		(*C.uchar)(&nonce[0]),
+		(*C.uchar)(&a.key[0]))
+
+	return
+}
+
+// Open decrypts a ciphertext using a nonce and additional data and appends the result to a destination.
+// See aead.AEAD for details.
+func (a *ChaCha20Poly1305IETF) Open(dst, nonce, ciphertext, additionalData []byte) (ret []byte, err error) {
+	support.CheckSize(nonce, a.NonceSize(), "nonce")
+	support.CheckSizeMin(ciphertext, a.Overhead(), "ciphertext")
+
+	ret, m := appendSlices(dst, len(ciphertext)-a.Overhead())
+
+	exit := C.crypto_aead_chacha20poly1305_ietf_decrypt(
+		(*C.uchar)(support.BytePointer(m)),
+		(*C.ulonglong)(nil),
+		(*C.uchar)(nil),
+		(*C.uchar)(&ciphertext[0]),
+		(C.ulonglong)(len(ciphertext)),
+		(*C.uchar)(support.BytePointer(additionalData)),
+		(C.ulonglong)(len(additionalData)),
+		(*C.uchar)(&nonce[0]),
+		(*C.uchar)(&a.key[0]))
+
+	if exit != 0 {
+		err = &support.VerificationError{}
+	}
+
+	return
+}
+
+// SealDetached encrypts plaintext using nonce and additional data and appends it to a destination.
+// See aead.AEAD for details.
+func (a *ChaCha20Poly1305IETF) SealDetached(dst, nonce, plaintext, additionalData []byte) (ret, mac []byte) {
+	support.CheckSize(nonce, a.NonceSize(), "nonce")
+
+	ret, c := appendSlices(dst, len(plaintext))
+	mac = make([]byte, a.Overhead())
+
+	C.crypto_aead_chacha20poly1305_ietf_encrypt_detached(
+		(*C.uchar)(support.BytePointer(c)),
+		(*C.uchar)(&mac[0]),
+		(*C.ulonglong)(nil),
+		(*C.uchar)(support.BytePointer(plaintext)),
+		(C.ulonglong)(len(plaintext)),
+		(*C.uchar)(support.BytePointer(additionalData)),
+		(C.ulonglong)(len(additionalData)),
+		(*C.uchar)(nil),
+		(*C.uchar)(&nonce[0]),
+		(*C.uchar)(&a.key[0]))
+
+	return
+}
+
+// OpenDetached decrypts a ciphertext using a nonce, mac and additional data and appends the result to a destination.
+// See aead.AEAD for details.
+func (a *ChaCha20Poly1305IETF) OpenDetached(dst, nonce, ciphertext, mac, additionalData []byte) (ret []byte, err error) {
+	support.CheckSize(nonce, a.NonceSize(), "nonce")
+	support.CheckSize(mac, a.Overhead(), "mac")
+
+	ret, m := appendSlices(dst, len(ciphertext))
+
+	exit := C.crypto_aead_chacha20poly1305_ietf_decrypt_detached(
+		(*C.uchar)(support.BytePointer(m)),
+		(*C.uchar)(nil),
+		(*C.uchar)(support.BytePointer(ciphertext)),
+		(C.ulonglong)(len(ciphertext)),
+		(*C.uchar)(&mac[0]),
+		(*C.uchar)(support.BytePointer(additionalData)),
+		(C.ulonglong)(len(additionalData)),
+		(*C.uchar)(&nonce[0]),
+		(*C.uchar)(&a.key[0]))
+
+	if exit != 0 {
+		err = &support.VerificationError{}
+	}
+
+	return
+}
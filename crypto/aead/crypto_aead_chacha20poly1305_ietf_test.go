@@ -0,0 +1,46 @@
+package aead
+
+import (
+	"bytes"
+	"github.com/GoKillers/libsodium-go/crypto/aead/chacha20poly1305ietf"
+	"testing"
+)
+
+func TestChaCha20Poly1305IETF(t *testing.T) {
+	k := chacha20poly1305ietf.GenerateKey()
+	nonce := new([chacha20poly1305ietf.NonceBytes]byte)
+	m := []byte("test message")
+	ad := []byte("additional data")
+	dst := []byte("dst")
+
+	ctx := NewChaCha20Poly1305IETF(k)
+
+	c := ctx.Seal(dst, nonce[:], m, ad)
+	if !bytes.Equal(c, append(dst, chacha20poly1305ietf.Encrypt(m, ad, nonce, k)...)) {
+		t.Fatal("Seal differs from chacha20poly1305ietf.Encrypt")
+	}
+
+	p, err := ctx.Open(dst, nonce[:], c[len(dst):], ad)
+	if err != nil || !bytes.Equal(p, append(dst, m...)) {
+		t.Fatalf("Open failed: %v", err)
+	}
+
+	dc, mac := ctx.SealDetached(nil, nonce[:], m, ad)
+	if !bytes.Equal(append(dc, mac...), c[len(dst):]) {
+		t.Fatal("SealDetached differs from Seal")
+	}
+
+	p, err = ctx.OpenDetached(nil, nonce[:], dc, mac, ad)
+	if err != nil || !bytes.Equal(p, m) {
+		t.Fatalf("OpenDetached failed: %v", err)
+	}
+
+	mac[0] ^= 1
+	if _, err = ctx.OpenDetached(nil, nonce[:], dc, mac, ad); err == nil {
+		t.Fatal("OpenDetached accepted a forged mac")
+	}
+
+	if _, err = ctx.Open(nil, nonce[:], c[len(dst):], nil); err == nil {
+		t.Fatal("Open accepted wrong additional data")
+	}
+}
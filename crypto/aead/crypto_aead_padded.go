@@ -0,0 +1,75 @@
+package aead
+
+import (
+	"github.com/GoKillers/libsodium-go/sodium"
+	"github.com/GoKillers/libsodium-go/support"
+)
+
+// Padded is an AEAD that pads plaintexts to a multiple of a block size
+// before encryption, hiding their exact length.
+type Padded struct {
+	AEAD
+	blockSize int
+}
+
+// NewPadded returns an AEAD that pads plaintexts to a multiple of `blockSize` bytes
+// using ISO/IEC 7816-4 padding before sealing them with `a`, and removes the padding
+// after opening. See sodium.Pad for details.
+func NewPadded(a AEAD, blockSize int) AEAD {
+	support.NilPanic(a == nil, "aead")
+	support.CheckIntInRange(blockSize, 1, 1<<30, "block")
+
+	return &Padded{AEAD: a, blockSize: blockSize}
+}
+
+// Seal pads and encrypts plaintext using nonce and additional data and appends it to a destination.
+// See aead.AEAD for details.
+func (p *Padded) Seal(dst, nonce, plaintext, additionalData []byte) []byte {
+	m := sodium.Pad(plaintext, p.blockSize)
+	defer sodium.MemZero(m)
+
+	return p.AEAD.Seal(dst, nonce, m, additionalData)
+}
+
+// Open decrypts a ciphertext using a nonce and additional data, removes the padding
+// and appends the result to a destination.
+// See aead.AEAD for details.
+func (p *Padded) Open(dst, nonce, ciphertext, additionalData []byte) ([]byte, error) {
+	ret, err := p.AEAD.Open(dst, nonce, ciphertext, additionalData)
+	if err != nil {
+		return ret, err
+	}
+
+	return p.unpad(ret, len(dst))
+}
+
+// SealDetached pads and encrypts plaintext using nonce and additional data and appends it to a destination.
+// See aead.AEAD for details.
+func (p *Padded) SealDetached(dst, nonce, plaintext, additionalData []byte) ([]byte, []byte) {
+	m := sodium.Pad(plaintext, p.blockSize)
+	defer sodium.MemZero(m)
+
+	return p.AEAD.SealDetached(dst, nonce, m, additionalData)
+}
+
+// OpenDetached decrypts a ciphertext using a nonce, mac and additional data, removes the padding
+// and appends the result to a destination.
+// See aead.AEAD for details.
+func (p *Padded) OpenDetached(dst, nonce, ciphertext, mac, additionalData []byte) ([]byte, error) {
+	ret, err := p.AEAD.OpenDetached(dst, nonce, ciphertext, mac, additionalData)
+	if err != nil {
+		return ret, err
+	}
+
+	return p.unpad(ret, len(dst))
+}
+
+// unpad removes the padding from the part of `ret` that was appended after `n` bytes.
+func (p *Padded) unpad(ret []byte, n int) ([]byte, error) {
+	m, err := sodium.Unpad(ret[n:], p.blockSize)
+	if err != nil {
+		return nil, err
+	}
+
+	return ret[:n+len(m)], nil
+}
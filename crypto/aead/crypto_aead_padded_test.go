@@ -0,0 +1,43 @@
+package aead
+
+import (
+	"bytes"
+	"github.com/GoKillers/libsodium-go/crypto/aead/aes256gcm"
+	"testing"
+)
+
+func TestPadded(t *testing.T) {
+	if !aes256gcm.IsAvailable() {
+		t.Skip("The CPU does not support this implementation of AES256GCM.")
+	}
+
+	ctx := NewPadded(NewAES256GCM(aes256gcm.GenerateKey()), 64)
+	nonce := make([]byte, ctx.NonceSize())
+	dst := []byte("dst")
+
+	for n := 0; n < 200; n++ {
+		m := bytes.Repeat([]byte{0x80}, n)
+
+		c := ctx.Seal(nil, nonce, m, nil)
+		if (len(c)-ctx.Overhead())%64 != 0 {
+			t.Fatalf("Ciphertext of %v bytes is not padded", len(c))
+		}
+
+		p, err := ctx.Open(dst, nonce, c, nil)
+		if err != nil || !bytes.Equal(p, append(dst, m...)) {
+			t.Fatalf("Decryption failed for %v bytes: %v", n, err)
+		}
+
+		c, mac := ctx.SealDetached(nil, nonce, m, nil)
+		p, err = ctx.OpenDetached(nil, nonce, c, mac, nil)
+		if err != nil || !bytes.Equal(p, m) {
+			t.Fatalf("Detached decryption failed for %v bytes: %v", n, err)
+		}
+	}
+
+	// Unpadded plaintexts are rejected
+	raw := NewAES256GCM(aes256gcm.GenerateKey())
+	if _, err := NewPadded(raw, 16).Open(nil, nonce, raw.Seal(nil, nonce, make([]byte, 16), nil), nil); err == nil {
+		t.Error("Invalid padding was accepted")
+	}
+}
@@ -0,0 +1,170 @@
+// Package envelope implements envelope encryption.
+//
+// A payload is encrypted with a fresh random data key, and the data key is
+// wrapped for each recipient: with secretbox under a shared key-encryption key,
+// or with an anonymous cryptobox under a recipient's public key. Any recipient
+// can unwrap the data key and decrypt the payload.
+//
+// An envelope is self-describing: its serialized form identifies the payload
+// cipher and the type of every recipient slot. The serialized header, including
+// all slots, is authenticated as additional data of the payload, so slots can
+// not be added or removed without the data key.
+package envelope
+
+import (
+	"errors"
+	"github.com/GoKillers/libsodium-go/crypto/aead/aes256gcm"
+	"github.com/GoKillers/libsodium-go/crypto/aead/xchacha20poly1305ietf"
+	"github.com/GoKillers/libsodium-go/randombytes"
+	"github.com/GoKillers/libsodium-go/sodium"
+)
+
+// DataKeyBytes is the size of a data key in bytes.
+const DataKeyBytes = xchacha20poly1305ietf.KeyBytes
+
+// Algorithm identifies the AEAD used to encrypt the payload.
+type Algorithm byte
+
+// Supported algorithms.
+const (
+	XChaCha20Poly1305IETF Algorithm = iota + 1 // XChaCha20-Poly1305-IETF
+	AES256GCM                                  // AES256-GCM, requires hardware support
+)
+
+// SlotType identifies how the data key is wrapped in a slot.
+type SlotType byte
+
+// Supported slot types.
+const (
+	SecretBoxSlot SlotType = iota + 1 // Data key wrapped with secretbox under a shared key
+	SealedBoxSlot                     // Data key wrapped with an anonymous cryptobox under a public key
+)
+
+// ErrNoMatchingSlot is returned by Open when no identity could unwrap any slot.
+var ErrNoMatchingSlot = errors.New("envelope: no recipient slot could be opened")
+
+// Slot holds the data key wrapped for one recipient.
+type Slot struct {
+	Type       SlotType
+	KeyID      []byte // Optional identifier of the recipient's key, at most 255 bytes
+	WrappedKey []byte
+}
+
+// Envelope is an encrypted payload with its wrapped data keys.
+type Envelope struct {
+	Algorithm  Algorithm
+	Slots      []Slot
+	Nonce      []byte
+	Ciphertext []byte
+}
+
+// Seal encrypts a plaintext and additional data `ad` with a new data key, and
+// wraps the data key for every recipient. The additional data is not included
+// in the envelope and must be passed to Open.
+func Seal(alg Algorithm, plaintext, ad []byte, recipients ...Recipient) (*Envelope, error) {
+	if len(recipients) == 0 {
+		return nil, errors.New("envelope: no recipients")
+	}
+
+	e := &Envelope{Algorithm: alg}
+
+	var dk [DataKeyBytes]byte
+	defer sodium.MemZero(dk[:])
+
+	switch alg {
+	case XChaCha20Poly1305IETF:
+		dk = *xchacha20poly1305ietf.GenerateKey()
+		e.Nonce = randombytes.RandomBytes(xchacha20poly1305ietf.NonceBytes)
+	case AES256GCM:
+		if !aes256gcm.IsAvailable() {
+			return nil, errors.New("envelope: AES256-GCM is not available on this CPU")
+		}
+		dk = *aes256gcm.GenerateKey()
+		e.Nonce = randombytes.RandomBytes(aes256gcm.NonceBytes)
+	default:
+		return nil, errors.New("envelope: unknown algorithm")
+	}
+
+	for _, r := range recipients {
+		s, err := r.wrap(dk[:])
+		if err != nil {
+			return nil, err
+		}
+		e.Slots = append(e.Slots, s)
+	}
+
+	header, err := e.marshalHeader()
+	if err != nil {
+		return nil, err
+	}
+
+	e.Ciphertext = seal(alg, plaintext, additionalData(header, ad), e.Nonce, &dk)
+
+	return e, nil
+}
+
+// Open unwraps the data key with the first identity that can open one of the
+// slots, and then verifies and decrypts the payload with additional data `ad`.
+// Every slot of a matching type is tried, so the key IDs of the slots are
+// optional. ErrNoMatchingSlot is returned if no slot can be opened.
+func (e *Envelope) Open(ad []byte, identities ...Identity) ([]byte, error) {
+	dk, err := e.unwrap(identities)
+	if err != nil {
+		return nil, err
+	}
+	defer sodium.MemZero(dk[:])
+
+	header, err := e.marshalHeader()
+	if err != nil {
+		return nil, err
+	}
+
+	return open(e.Algorithm, e.Ciphertext, additionalData(header, ad), e.Nonce, dk)
+}
+
+// unwrap returns the data key from the first slot that one of the identities can open.
+func (e *Envelope) unwrap(identities []Identity) (*[DataKeyBytes]byte, error) {
+	for _, id := range identities {
+		for _, s := range e.Slots {
+			if dk, ok := id.unwrap(s); ok {
+				return dk, nil
+			}
+		}
+	}
+
+	return nil, ErrNoMatchingSlot
+}
+
+// additionalData returns the additional data of the payload: the header followed by `ad`.
+func additionalData(header, ad []byte) []byte {
+	return append(header[:len(header):len(header)], ad...)
+}
+
+// seal encrypts the payload with a data key.
+func seal(alg Algorithm, m, ad, nonce []byte, dk *[DataKeyBytes]byte) []byte {
+	if alg == AES256GCM {
+		return aes256gcm.Seal(nil, m, ad, (*[aes256gcm.NonceBytes]byte)(nonce), (*[aes256gcm.KeyBytes]byte)(dk))
+	}
+	return xchacha20poly1305ietf.Seal(nil, m, ad, (*[xchacha20poly1305ietf.NonceBytes]byte)(nonce), dk)
+}
+
+// open verifies and decrypts the payload with a data key.
+func open(alg Algorithm, c, ad, nonce []byte, dk *[DataKeyBytes]byte) ([]byte, error) {
+	switch alg {
+	case XChaCha20Poly1305IETF:
+		if len(nonce) != xchacha20poly1305ietf.NonceBytes || len(c) < xchacha20poly1305ietf.ABytes {
+			return nil, errInvalid
+		}
+		return xchacha20poly1305ietf.Open(nil, c, ad, (*[xchacha20poly1305ietf.NonceBytes]byte)(nonce), dk)
+	case AES256GCM:
+		if !aes256gcm.IsAvailable() {
+			return nil, errors.New("envelope: AES256-GCM is not available on this CPU")
+		}
+		if len(nonce) != aes256gcm.NonceBytes || len(c) < aes256gcm.ABytes {
+			return nil, errInvalid
+		}
+		return aes256gcm.Open(nil, c, ad, (*[aes256gcm.NonceBytes]byte)(nonce), (*[aes256gcm.KeyBytes]byte)(dk))
+	default:
+		return nil, errors.New("envelope: unknown algorithm")
+	}
+}
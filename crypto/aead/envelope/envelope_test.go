@@ -0,0 +1,85 @@
+package envelope
+
+import (
+	"bytes"
+	"github.com/GoKillers/libsodium-go/crypto/aead/aes256gcm"
+	"github.com/GoKillers/libsodium-go/cryptobox"
+	"github.com/GoKillers/libsodium-go/cryptosecretbox"
+	"github.com/GoKillers/libsodium-go/randombytes"
+	"testing"
+)
+
+func TestSealOpen(t *testing.T) {
+	kek := &SecretBox{KeyID: []byte("kek-1"), Key: randombytes.RandomBytes(secretbox.CryptoSecretBoxKeyBytes())}
+
+	sk1, pk1, _ := cryptobox.CryptoBoxKeyPair()
+	sk2, pk2, _ := cryptobox.CryptoBoxKeyPair()
+	alice := &SealedBox{PublicKey: pk1, SecretKey: sk1}
+	bob := &SealedBox{KeyID: []byte("bob"), PublicKey: pk2, SecretKey: sk2}
+
+	m := []byte("test payload")
+	ad := []byte("object-42")
+
+	for _, alg := range []Algorithm{XChaCha20Poly1305IETF, AES256GCM} {
+		if alg == AES256GCM && !aes256gcm.IsAvailable() {
+			continue
+		}
+
+		e, err := Seal(alg, m, ad, kek, &SealedBox{PublicKey: pk1}, &SealedBox{KeyID: []byte("bob"), PublicKey: pk2})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		data, err := e.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var restored Envelope
+		if err := restored.UnmarshalBinary(data); err != nil {
+			t.Fatal(err)
+		}
+
+		// Every recipient can open the envelope
+		for _, id := range []Identity{kek, alice, bob} {
+			p, err := restored.Open(ad, id)
+			if err != nil || !bytes.Equal(p, m) {
+				t.Fatalf("Open failed: %v", err)
+			}
+		}
+
+		// Identities are tried in order
+		stranger := &SecretBox{Key: randombytes.RandomBytes(secretbox.CryptoSecretBoxKeyBytes())}
+		if p, err := restored.Open(ad, stranger, bob); err != nil || !bytes.Equal(p, m) {
+			t.Fatalf("Open with several identities failed: %v", err)
+		}
+
+		if _, err := restored.Open(ad, stranger); err != ErrNoMatchingSlot {
+			t.Fatalf("Expected ErrNoMatchingSlot, got %v", err)
+		}
+
+		if _, err := restored.Open([]byte("object-43"), kek); err == nil {
+			t.Fatal("Wrong additional data accepted")
+		}
+
+		// Removing a slot is detected
+		restored.Slots = restored.Slots[1:]
+		if _, err := restored.Open(ad, bob); err == nil {
+			t.Fatal("Removed slot not detected")
+		}
+	}
+}
+
+func TestUnmarshalInvalid(t *testing.T) {
+	kek := &SecretBox{Key: randombytes.RandomBytes(secretbox.CryptoSecretBoxKeyBytes())}
+	e, _ := Seal(XChaCha20Poly1305IETF, []byte("m"), nil, kek)
+	data, _ := e.MarshalBinary()
+
+	// Truncating into the header, nonce or tag is rejected; the payload is 1 byte
+	var restored Envelope
+	for i := 0; i < len(data)-1; i++ {
+		if restored.UnmarshalBinary(data[:i]) == nil {
+			t.Fatalf("Truncated envelope of %d bytes accepted", i)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package envelope
+
+import (
+	"encoding/binary"
+	"errors"
+	"github.com/GoKillers/libsodium-go/crypto/aead/aes256gcm"
+	"github.com/GoKillers/libsodium-go/crypto/aead/xchacha20poly1305ietf"
+)
+
+// magic identifies a serialized envelope and the version of its format.
+const magic = "LSEV\x01"
+
+var errInvalid = errors.New("envelope: invalid envelope")
+
+// The serialized form of an envelope is
+//
+//	magic | algorithm | number of slots (2 bytes) | slots | nonce | ciphertext
+//
+// where each slot is
+//
+//	type | key ID length | key ID | wrapped key length (2 bytes) | wrapped key
+//
+// All integers are big-endian. The size of the nonce follows from the algorithm.
+
+// marshalHeader returns the serialized envelope up to and including the slots.
+func (e *Envelope) marshalHeader() ([]byte, error) {
+	if len(e.Slots) > 0xffff {
+		return nil, errors.New("envelope: too many slots")
+	}
+
+	b := append([]byte(magic), byte(e.Algorithm))
+	b = binary.BigEndian.AppendUint16(b, uint16(len(e.Slots)))
+
+	for _, s := range e.Slots {
+		if len(s.KeyID) > 0xff || len(s.WrappedKey) > 0xffff {
+			return nil, errors.New("envelope: slot too large")
+		}
+
+		b = append(b, byte(s.Type), byte(len(s.KeyID)))
+		b = append(b, s.KeyID...)
+		b = binary.BigEndian.AppendUint16(b, uint16(len(s.WrappedKey)))
+		b = append(b, s.WrappedKey...)
+	}
+
+	return b, nil
+}
+
+// MarshalBinary serializes the envelope.
+func (e *Envelope) MarshalBinary() ([]byte, error) {
+	b, err := e.marshalHeader()
+	if err != nil {
+		return nil, err
+	}
+
+	b = append(b, e.Nonce...)
+
+	return append(b, e.Ciphertext...), nil
+}
+
+// UnmarshalBinary restores an envelope serialized by MarshalBinary.
+// The envelope is only checked for consistency, and authenticated by Open.
+func (e *Envelope) UnmarshalBinary(data []byte) error {
+	if len(data) < len(magic)+3 || string(data[:len(magic)]) != magic {
+		return errInvalid
+	}
+
+	alg := Algorithm(data[len(magic)])
+	count := int(binary.BigEndian.Uint16(data[len(magic)+1:]))
+	p := data[len(magic)+3:]
+
+	slots := make([]Slot, count)
+	for i := range slots {
+		if len(p) < 2 || len(p) < 2+int(p[1])+2 {
+			return errInvalid
+		}
+
+		s := Slot{Type: SlotType(p[0])}
+		s.KeyID = append([]byte(nil), p[2:2+int(p[1])]...)
+		p = p[2+len(s.KeyID):]
+
+		n := int(binary.BigEndian.Uint16(p))
+		if len(p) < 2+n {
+			return errInvalid
+		}
+
+		s.WrappedKey = append([]byte(nil), p[2:2+n]...)
+		p = p[2+n:]
+		slots[i] = s
+	}
+
+	var nonceSize, overhead int
+	switch alg {
+	case XChaCha20Poly1305IETF:
+		nonceSize, overhead = xchacha20poly1305ietf.NonceBytes, xchacha20poly1305ietf.ABytes
+	case AES256GCM:
+		nonceSize, overhead = aes256gcm.NonceBytes, aes256gcm.ABytes
+	default:
+		return errors.New("envelope: unknown algorithm")
+	}
+
+	if len(p) < nonceSize+overhead {
+		return errInvalid
+	}
+
+	*e = Envelope{
+		Algorithm:  alg,
+		Slots:      slots,
+		Nonce:      append([]byte(nil), p[:nonceSize]...),
+		Ciphertext: append([]byte(nil), p[nonceSize:]...),
+	}
+
+	return nil
+}
@@ -0,0 +1,103 @@
+package envelope
+
+import (
+	"bytes"
+	"github.com/GoKillers/libsodium-go/cryptobox"
+	"github.com/GoKillers/libsodium-go/cryptosecretbox"
+	"github.com/GoKillers/libsodium-go/randombytes"
+	"github.com/GoKillers/libsodium-go/support"
+)
+
+// Recipient wraps data keys for a recipient of an envelope.
+type Recipient interface {
+	wrap(dk []byte) (Slot, error)
+}
+
+// Identity unwraps data keys wrapped for a recipient.
+type Identity interface {
+	unwrap(s Slot) (*[DataKeyBytes]byte, bool)
+}
+
+// SecretBox is a recipient and identity sharing a key-encryption key `Key`,
+// used to wrap the data key with secretbox. The optional KeyID is stored
+// in the slot, and only slots with the same or no key ID are tried.
+type SecretBox struct {
+	KeyID []byte
+	Key   []byte // Key of secretbox.CryptoSecretBoxKeyBytes() bytes
+}
+
+func (r *SecretBox) wrap(dk []byte) (Slot, error) {
+	if len(r.Key) != secretbox.CryptoSecretBoxKeyBytes() {
+		return Slot{}, support.KeySizeError(len(r.Key))
+	}
+
+	n := randombytes.RandomBytes(secretbox.CryptoSecretBoxNonceBytes())
+
+	return Slot{
+		Type:       SecretBoxSlot,
+		KeyID:      r.KeyID,
+		WrappedKey: secretbox.Seal(n, dk, n, r.Key),
+	}, nil
+}
+
+func (r *SecretBox) unwrap(s Slot) (*[DataKeyBytes]byte, bool) {
+	nonceSize := secretbox.CryptoSecretBoxNonceBytes()
+
+	if s.Type != SecretBoxSlot || !matchKeyID(r.KeyID, s.KeyID) ||
+		len(r.Key) != secretbox.CryptoSecretBoxKeyBytes() ||
+		len(s.WrappedKey) != nonceSize+DataKeyBytes+secretbox.CryptoSecretBoxMacBytes() {
+		return nil, false
+	}
+
+	dk := new([DataKeyBytes]byte)
+	if _, err := secretbox.Open(dk[:0], s.WrappedKey[nonceSize:], s.WrappedKey[:nonceSize], r.Key); err != nil {
+		return nil, false
+	}
+
+	return dk, true
+}
+
+// SealedBox is a recipient identified by a public key `PublicKey`, for which
+// the data key is wrapped with an anonymous cryptobox. To unwrap data keys,
+// the SecretKey of the recipient must be set as well. The optional KeyID is
+// stored in the slot, and only slots with the same or no key ID are tried.
+type SealedBox struct {
+	KeyID     []byte
+	PublicKey []byte
+	SecretKey []byte
+}
+
+func (r *SealedBox) wrap(dk []byte) (Slot, error) {
+	if len(r.PublicKey) != cryptobox.CryptoBoxPublicKeyBytes() {
+		return Slot{}, support.KeySizeError(len(r.PublicKey))
+	}
+
+	c, err := cryptobox.SealAnonymous(nil, dk, r.PublicKey)
+	if err != nil {
+		return Slot{}, err
+	}
+
+	return Slot{Type: SealedBoxSlot, KeyID: r.KeyID, WrappedKey: c}, nil
+}
+
+func (r *SealedBox) unwrap(s Slot) (*[DataKeyBytes]byte, bool) {
+	if s.Type != SealedBoxSlot || !matchKeyID(r.KeyID, s.KeyID) ||
+		len(r.PublicKey) != cryptobox.CryptoBoxPublicKeyBytes() ||
+		len(r.SecretKey) != cryptobox.CryptoBoxSecretKeyBytes() ||
+		len(s.WrappedKey) != DataKeyBytes+cryptobox.CryptoBoxSealBytes() {
+		return nil, false
+	}
+
+	dk := new([DataKeyBytes]byte)
+	if _, err := cryptobox.OpenAnonymous(dk[:0], s.WrappedKey, r.PublicKey, r.SecretKey); err != nil {
+		return nil, false
+	}
+
+	return dk, true
+}
+
+// matchKeyID returns true if a slot with key ID `slot` may belong to
+// an identity with key ID `id`, which is the case if either is empty.
+func matchKeyID(id, slot []byte) bool {
+	return len(id) == 0 || len(slot) == 0 || bytes.Equal(id, slot)
+}
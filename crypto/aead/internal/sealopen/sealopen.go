@@ -0,0 +1,36 @@
+// Package sealopen implements the append-style Seal and Open functions of the
+// AEAD packages, which only differ in the C functions they call.
+package sealopen
+
+import "github.com/GoKillers/libsodium-go/support"
+
+// Cipher holds the size of the authentication tag and the C functions of an AEAD.
+type Cipher struct {
+	ABytes int
+
+	// Encrypt encrypts `m` into `c`, which is ABytes longer.
+	Encrypt func(c, m, ad, nonce, k []byte)
+
+	// Decrypt decrypts `c` into `m`, which is ABytes shorter, and returns 0 on success.
+	Decrypt func(m, c, ad, nonce, k []byte) int
+}
+
+// Seal encrypts a message and appends the ciphertext to `dst`, returning the updated slice.
+func (x *Cipher) Seal(dst, m, ad, nonce, k []byte) []byte {
+	ret, c := support.SliceForAppend(dst, len(m)+x.ABytes)
+	x.Encrypt(c, m, ad, nonce, k)
+
+	return ret
+}
+
+// Open decrypts and verifies a ciphertext and appends the message to `dst`, returning the updated slice.
+func (x *Cipher) Open(dst, c, ad, nonce, k []byte) ([]byte, error) {
+	support.CheckSizeMin(c, x.ABytes, "ciphertext")
+
+	ret, m := support.SliceForAppend(dst, len(c)-x.ABytes)
+	if x.Decrypt(m, c, ad, nonce, k) != 0 {
+		return nil, &support.VerificationError{}
+	}
+
+	return ret, nil
+}
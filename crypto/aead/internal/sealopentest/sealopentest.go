@@ -0,0 +1,82 @@
+// Package sealopentest tests the Seal and Open functions of the AEAD packages.
+package sealopentest
+
+import (
+	"bytes"
+	"github.com/GoKillers/libsodium-go/randombytes"
+	"testing"
+)
+
+// Cipher is the API of an AEAD package, with nonces and keys as slices.
+type Cipher struct {
+	KeyBytes   int
+	NonceBytes int
+	ABytes     int
+	Seal       func(dst, m, ad, nonce, k []byte) []byte
+	Open       func(dst, c, ad, nonce, k []byte) ([]byte, error)
+	Encrypt    func(m, ad, nonce, k []byte) []byte
+}
+
+// Test checks Seal and Open against Encrypt, including in-place use and forgeries.
+func Test(t *testing.T, x *Cipher) {
+	k := randombytes.RandomBytes(x.KeyBytes)
+	nonce := make([]byte, x.NonceBytes)
+	m := []byte("test message")
+	ad := []byte("additional data")
+	dst := []byte("dst")
+
+	c := x.Seal(dst, m, ad, nonce, k)
+	if !bytes.Equal(c, append(dst, x.Encrypt(m, ad, nonce, k)...)) {
+		t.Fatal("Seal does not match Encrypt")
+	}
+
+	p, err := x.Open(dst, c[len(dst):], ad, nonce, k)
+	if err != nil || !bytes.Equal(p, append(dst, m...)) {
+		t.Fatalf("Open failed: %v", err)
+	}
+
+	// In-place encryption and decryption
+	buf := make([]byte, len(m), len(m)+x.ABytes)
+	copy(buf, m)
+	c = x.Seal(buf[:0], buf, ad, nonce, k)
+	p, err = x.Open(c[:0], c, ad, nonce, k)
+	if err != nil || !bytes.Equal(p, m) {
+		t.Fatalf("In-place decryption failed: %v", err)
+	}
+
+	c = x.Seal(nil, m, ad, nonce, k)
+	c[0] ^= 1
+	if _, err := x.Open(nil, c, ad, nonce, k); err == nil {
+		t.Fatal("Open unexpectedly succeeded")
+	}
+}
+
+// BenchmarkSeal measures Seal on 1 KiB messages.
+func BenchmarkSeal(b *testing.B, x *Cipher) {
+	k := randombytes.RandomBytes(x.KeyBytes)
+	nonce := make([]byte, x.NonceBytes)
+	m := make([]byte, 1024)
+	buf := make([]byte, 0, len(m)+x.ABytes)
+
+	b.ReportAllocs()
+	b.SetBytes(int64(len(m)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		x.Seal(buf, m, nil, nonce, k)
+	}
+}
+
+// BenchmarkOpen measures Open on 1 KiB messages.
+func BenchmarkOpen(b *testing.B, x *Cipher) {
+	k := randombytes.RandomBytes(x.KeyBytes)
+	nonce := make([]byte, x.NonceBytes)
+	c := x.Seal(nil, make([]byte, 1024), nil, nonce, k)
+	buf := make([]byte, 0, len(c))
+
+	b.ReportAllocs()
+	b.SetBytes(int64(len(c) - x.ABytes))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		x.Open(buf, c, nil, nonce, k)
+	}
+}
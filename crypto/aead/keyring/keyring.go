@@ -0,0 +1,338 @@
+// Package keyring implements key rotation for authenticated encryption.
+//
+// A Keyring holds numbered versions of AEAD keys, one of which is the primary
+// key. Messages are encrypted with the primary key and prefixed with the ID of
+// that key, so they can still be decrypted after a newer key has become primary,
+// as long as the old key remains in the keyring. Old ciphertexts can be
+// re-encrypted with the primary key, after which the old key can be removed.
+//
+// A ciphertext has the format
+//
+//	key ID (4 bytes, big-endian) | nonce | AEAD ciphertext
+//
+// where the nonce is random, and the key ID is authenticated as additional data.
+//
+// Only AEADs with nonces large enough to be random are supported, so the
+// original ChaCha20-Poly1305 construction, with 64-bit nonces, is not. With the
+// 96-bit nonces of AES256GCM and ChaCha20Poly1305IETF, a key should not encrypt
+// more than 2^32 messages; XChaCha20Poly1305IETF has no practical limit.
+package keyring
+
+import (
+	"encoding/binary"
+	"errors"
+	"github.com/GoKillers/libsodium-go/crypto/aead"
+	"github.com/GoKillers/libsodium-go/crypto/aead/aes256gcm"
+	"github.com/GoKillers/libsodium-go/crypto/aead/chacha20poly1305ietf"
+	"github.com/GoKillers/libsodium-go/crypto/aead/xchacha20poly1305ietf"
+	"github.com/GoKillers/libsodium-go/randombytes"
+	"github.com/GoKillers/libsodium-go/sodium"
+	"github.com/GoKillers/libsodium-go/support"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// KeyID identifies a version of a key in a Keyring.
+type KeyID uint32
+
+// KeyIDBytes is the size of the key ID prefix of a ciphertext.
+const KeyIDBytes = 4
+
+// Algorithm identifies the AEAD used with a key.
+type Algorithm byte
+
+// Supported algorithms.
+const (
+	XChaCha20Poly1305IETF Algorithm = iota + 1 // XChaCha20-Poly1305-IETF
+	AES256GCM                                  // AES256-GCM, requires hardware support
+	ChaCha20Poly1305IETF                       // ChaCha20-Poly1305-IETF
+)
+
+// KeySize returns the size of a key for the algorithm in bytes, or 0 if the algorithm is unknown.
+func (a Algorithm) KeySize() int {
+	switch a {
+	case XChaCha20Poly1305IETF:
+		return xchacha20poly1305ietf.KeyBytes
+	case AES256GCM:
+		return aes256gcm.KeyBytes
+	case ChaCha20Poly1305IETF:
+		return chacha20poly1305ietf.KeyBytes
+	default:
+		return 0
+	}
+}
+
+// newAEAD returns an AEAD for a key.
+func (a Algorithm) newAEAD(k []byte) (aead.AEAD, error) {
+	if len(k) != a.KeySize() {
+		return nil, support.KeySizeError(len(k))
+	}
+
+	switch a {
+	case XChaCha20Poly1305IETF:
+		return aead.NewXChaCha20Poly1305IETF((*[xchacha20poly1305ietf.KeyBytes]byte)(k)), nil
+	case AES256GCM:
+		if !aes256gcm.IsAvailable() {
+			return nil, errors.New("keyring: AES256-GCM is not available on this CPU")
+		}
+		return aead.NewAES256GCM((*[aes256gcm.KeyBytes]byte)(k)), nil
+	case ChaCha20Poly1305IETF:
+		return aead.NewChaCha20Poly1305IETF((*[chacha20poly1305ietf.KeyBytes]byte)(k)), nil
+	default:
+		return nil, errors.New("keyring: unknown algorithm")
+	}
+}
+
+// UnknownKeyError is an error that occurs when a ciphertext was encrypted
+// with a key that is not in the keyring.
+type UnknownKeyError KeyID
+
+func (k UnknownKeyError) Error() string {
+	return "keyring: unknown key " + strconv.FormatUint(uint64(k), 10)
+}
+
+// key is a version of a key in a keyring.
+type key struct {
+	alg  Algorithm
+	raw  []byte
+	aead aead.AEAD
+}
+
+// wipe zeroes the key and the copy held by its AEAD.
+func (k *key) wipe() {
+	sodium.MemZero(k.raw)
+	if w, ok := k.aead.(interface{ Wipe() }); ok {
+		w.Wipe()
+	}
+}
+
+// Keyring holds versions of keys and encrypts with the primary one.
+// A Keyring is safe for concurrent use.
+type Keyring struct {
+	mu      sync.RWMutex
+	keys    map[KeyID]*key
+	primary KeyID
+}
+
+// New returns an empty Keyring. A key must be added before encrypting.
+func New() *Keyring {
+	return &Keyring{keys: make(map[KeyID]*key)}
+}
+
+// Rotate generates a random key for an algorithm, adds it to the keyring
+// and makes it the primary key. It returns the ID of the new key.
+func (kr *Keyring) Rotate(alg Algorithm) (KeyID, error) {
+	k := randombytes.RandomBytes(alg.KeySize())
+	defer sodium.MemZero(k)
+
+	kr.mu.Lock()
+	defer kr.mu.Unlock()
+
+	id, err := kr.addNext(alg, k)
+	if err != nil {
+		return 0, err
+	}
+
+	kr.primary = id
+
+	return id, nil
+}
+
+// Add adds a copy of a key for an algorithm to the keyring, with an ID one higher
+// than the highest ID in the keyring. The first key added becomes the primary key.
+func (kr *Keyring) Add(alg Algorithm, k []byte) (KeyID, error) {
+	kr.mu.Lock()
+	defer kr.mu.Unlock()
+
+	id, err := kr.addNext(alg, k)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(kr.keys) == 1 {
+		kr.primary = id
+	}
+
+	return id, nil
+}
+
+// addNext adds a copy of a key with an ID one higher than the highest ID
+// in the keyring. The caller must hold the lock.
+func (kr *Keyring) addNext(alg Algorithm, k []byte) (KeyID, error) {
+	var id KeyID
+	for i := range kr.keys {
+		if i >= id {
+			id = i + 1
+		}
+	}
+
+	if id == 0 && len(kr.keys) > 0 {
+		return 0, errors.New("keyring: key IDs exhausted")
+	}
+
+	if err := kr.add(id, alg, k); err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// add adds a copy of a key with a given ID. The caller must hold the lock.
+func (kr *Keyring) add(id KeyID, alg Algorithm, k []byte) error {
+	if _, ok := kr.keys[id]; ok {
+		return errors.New("keyring: duplicate key ID")
+	}
+
+	raw := make([]byte, len(k))
+	copy(raw, k)
+
+	a, err := alg.newAEAD(raw)
+	if err != nil {
+		return err
+	}
+
+	kr.keys[id] = &key{alg: alg, raw: raw, aead: a}
+
+	return nil
+}
+
+// SetPrimary makes the key with ID `id` the primary key.
+func (kr *Keyring) SetPrimary(id KeyID) error {
+	kr.mu.Lock()
+	defer kr.mu.Unlock()
+
+	if _, ok := kr.keys[id]; !ok {
+		return UnknownKeyError(id)
+	}
+
+	kr.primary = id
+
+	return nil
+}
+
+// Primary returns the ID of the primary key.
+func (kr *Keyring) Primary() KeyID {
+	kr.mu.RLock()
+	defer kr.mu.RUnlock()
+
+	return kr.primary
+}
+
+// IDs returns the IDs of all keys in the keyring in increasing order.
+func (kr *Keyring) IDs() []KeyID {
+	kr.mu.RLock()
+	defer kr.mu.RUnlock()
+
+	ids := make([]KeyID, 0, len(kr.keys))
+	for id := range kr.keys {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	return ids
+}
+
+// Remove removes the key with ID `id`, after which ciphertexts encrypted
+// with it can no longer be decrypted. The primary key can not be removed.
+func (kr *Keyring) Remove(id KeyID) error {
+	kr.mu.Lock()
+	defer kr.mu.Unlock()
+
+	k, ok := kr.keys[id]
+	if !ok {
+		return UnknownKeyError(id)
+	}
+
+	if id == kr.primary {
+		return errors.New("keyring: can not remove the primary key")
+	}
+
+	k.wipe()
+	delete(kr.keys, id)
+
+	return nil
+}
+
+// Encrypt encrypts and authenticates a plaintext and additional data `ad`
+// with the primary key. The additional data is not included in the ciphertext.
+func (kr *Keyring) Encrypt(plaintext, ad []byte) ([]byte, error) {
+	// The lock is held until the key is used, since Remove wipes it
+	kr.mu.RLock()
+	defer kr.mu.RUnlock()
+
+	id := kr.primary
+	k, ok := kr.keys[id]
+	if !ok {
+		return nil, errors.New("keyring: no primary key")
+	}
+
+	return k.seal(id, plaintext, ad), nil
+}
+
+// seal encrypts a plaintext with a key and prefixes it with the key ID and nonce.
+func (k *key) seal(id KeyID, plaintext, ad []byte) []byte {
+	n := k.aead.NonceSize()
+	out := make([]byte, KeyIDBytes+n, KeyIDBytes+n+len(plaintext)+k.aead.Overhead())
+
+	binary.BigEndian.PutUint32(out, uint32(id))
+	randombytes.RandomBytesBuf(out[KeyIDBytes:])
+
+	return k.aead.Seal(out, out[KeyIDBytes:], plaintext, additionalData(out[:KeyIDBytes], ad))
+}
+
+// additionalData returns the additional data of a message: its key ID followed by `ad`.
+func additionalData(id, ad []byte) []byte {
+	return append(append(make([]byte, 0, len(id)+len(ad)), id...), ad...)
+}
+
+// KeyIDOf returns the ID of the key used to encrypt a ciphertext.
+func KeyIDOf(ciphertext []byte) (KeyID, error) {
+	if len(ciphertext) < KeyIDBytes {
+		return 0, errors.New("keyring: ciphertext too short")
+	}
+
+	return KeyID(binary.BigEndian.Uint32(ciphertext)), nil
+}
+
+// Decrypt verifies and decrypts a ciphertext with additional data `ad`,
+// using the key it was encrypted with.
+func (kr *Keyring) Decrypt(ciphertext, ad []byte) ([]byte, error) {
+	id, err := KeyIDOf(ciphertext)
+	if err != nil {
+		return nil, err
+	}
+
+	kr.mu.RLock()
+	defer kr.mu.RUnlock()
+
+	k, ok := kr.keys[id]
+	if !ok {
+		return nil, UnknownKeyError(id)
+	}
+
+	n := k.aead.NonceSize()
+	if len(ciphertext) < KeyIDBytes+n+k.aead.Overhead() {
+		return nil, errors.New("keyring: ciphertext too short")
+	}
+
+	return k.aead.Open(nil, ciphertext[KeyIDBytes:KeyIDBytes+n], ciphertext[KeyIDBytes+n:],
+		additionalData(ciphertext[:KeyIDBytes], ad))
+}
+
+// Reencrypt decrypts a ciphertext and encrypts it again with the primary key.
+// A ciphertext that is already encrypted with the primary key is returned unchanged,
+// after being verified.
+func (kr *Keyring) Reencrypt(ciphertext, ad []byte) ([]byte, error) {
+	m, err := kr.Decrypt(ciphertext, ad)
+	if err != nil {
+		return nil, err
+	}
+	defer sodium.MemZero(m)
+
+	if id, _ := KeyIDOf(ciphertext); id == kr.Primary() {
+		return ciphertext, nil
+	}
+
+	return kr.Encrypt(m, ad)
+}
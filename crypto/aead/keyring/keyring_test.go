@@ -0,0 +1,189 @@
+package keyring
+
+import (
+	"bytes"
+	"github.com/GoKillers/libsodium-go/crypto/aead/aes256gcm"
+	"github.com/GoKillers/libsodium-go/randombytes"
+	"github.com/GoKillers/libsodium-go/sodium"
+	"sync"
+	"testing"
+)
+
+func TestRotation(t *testing.T) {
+	kr := New()
+
+	if _, err := kr.Encrypt([]byte("m"), nil); err == nil {
+		t.Fatal("Empty keyring encrypted a message")
+	}
+
+	id1, err := kr.Rotate(XChaCha20Poly1305IETF)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := []byte("test message")
+	ad := []byte("additional data")
+	c1, err := kr.Encrypt(m, ad)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if id, _ := KeyIDOf(c1); id != id1 {
+		t.Fatalf("Wrong key ID %d", id)
+	}
+
+	alg := XChaCha20Poly1305IETF
+	if aes256gcm.IsAvailable() {
+		alg = AES256GCM
+	}
+
+	id2, err := kr.Rotate(alg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if id2 != id1+1 || kr.Primary() != id2 {
+		t.Fatalf("Wrong new key ID %d", id2)
+	}
+
+	// Old ciphertexts can still be decrypted
+	p, err := kr.Decrypt(c1, ad)
+	if err != nil || !bytes.Equal(p, m) {
+		t.Fatalf("Decryption with an old key failed: %v", err)
+	}
+
+	if _, err := kr.Decrypt(c1, nil); err == nil {
+		t.Fatal("Wrong additional data accepted")
+	}
+
+	// Re-encryption moves them to the primary key
+	c2, err := kr.Reencrypt(c1, ad)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if id, _ := KeyIDOf(c2); id != id2 {
+		t.Fatal("Re-encryption did not use the primary key")
+	}
+
+	if c3, _ := kr.Reencrypt(c2, ad); !bytes.Equal(c2, c3) {
+		t.Fatal("Re-encryption changed an up-to-date ciphertext")
+	}
+
+	if err := kr.Remove(id2); err == nil {
+		t.Fatal("Primary key removed")
+	}
+
+	old := kr.keys[id1]
+	if err := kr.Remove(id1); err != nil {
+		t.Fatal(err)
+	}
+
+	// The key and the copy held by its AEAD are wiped
+	n := old.aead.NonceSize()
+	if !sodium.IsZero(old.raw) {
+		t.Fatal("Removed key not wiped")
+	}
+	if _, err := old.aead.Open(nil, c1[KeyIDBytes:KeyIDBytes+n], c1[KeyIDBytes+n:], additionalData(c1[:KeyIDBytes], ad)); err == nil {
+		t.Fatal("AEAD of a removed key still decrypts")
+	}
+
+	if _, err := kr.Decrypt(c1, ad); err != UnknownKeyError(id1) {
+		t.Fatalf("Expected UnknownKeyError, got %v", err)
+	}
+
+	if p, err := kr.Decrypt(c2, ad); err != nil || !bytes.Equal(p, m) {
+		t.Fatalf("Decryption of re-encrypted message failed: %v", err)
+	}
+}
+
+func TestAlgorithms(t *testing.T) {
+	for _, alg := range []Algorithm{XChaCha20Poly1305IETF, AES256GCM, ChaCha20Poly1305IETF} {
+		if alg == AES256GCM && !aes256gcm.IsAvailable() {
+			continue
+		}
+
+		kr := New()
+		if _, err := kr.Rotate(alg); err != nil {
+			t.Fatalf("Algorithm %d: %v", alg, err)
+		}
+
+		c, _ := kr.Encrypt([]byte("test message"), nil)
+		if p, err := kr.Decrypt(c, nil); err != nil || string(p) != "test message" {
+			t.Fatalf("Algorithm %d: decryption failed: %v", alg, err)
+		}
+	}
+
+	if _, err := New().Rotate(Algorithm(0)); err == nil {
+		t.Fatal("Unknown algorithm accepted")
+	}
+}
+
+func TestConcurrentRotate(t *testing.T) {
+	for round := 0; round < 50; round++ {
+		kr := New()
+
+		var wg sync.WaitGroup
+		for i := 0; i < 64; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if _, err := kr.Rotate(XChaCha20Poly1305IETF); err != nil {
+					t.Error(err)
+				}
+			}()
+		}
+		wg.Wait()
+
+		// The newest key is the primary one
+		if ids := kr.IDs(); len(ids) != 64 || kr.Primary() != 63 {
+			t.Fatalf("Keys %v, primary %d", ids, kr.Primary())
+		}
+	}
+}
+
+func TestKeyIDAuthenticated(t *testing.T) {
+	// The same key under two IDs
+	k := randombytes.RandomBytes(XChaCha20Poly1305IETF.KeySize())
+	kr := New()
+	id1, _ := kr.Add(XChaCha20Poly1305IETF, k)
+	id2, _ := kr.Add(XChaCha20Poly1305IETF, k)
+
+	c, _ := kr.Encrypt([]byte("test message"), nil)
+	c[KeyIDBytes-1] ^= byte(id1 ^ id2)
+
+	if _, err := kr.Decrypt(c, nil); err == nil {
+		t.Fatal("Modified key ID accepted")
+	}
+}
+
+func TestMarshal(t *testing.T) {
+	kr := New()
+	kr.Rotate(XChaCha20Poly1305IETF)
+	kr.Rotate(XChaCha20Poly1305IETF)
+	kr.Add(XChaCha20Poly1305IETF, randombytes.RandomBytes(XChaCha20Poly1305IETF.KeySize()))
+
+	c, _ := kr.Encrypt([]byte("test message"), nil)
+
+	master := new([MasterKeyBytes]byte)
+	randombytes.RandomBytesBuf(master[:])
+	data := kr.Marshal(master)
+
+	restored, err := Unmarshal(data, master)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if restored.Primary() != kr.Primary() || len(restored.IDs()) != 3 {
+		t.Fatalf("Restored keyring differs: %v, primary %d", restored.IDs(), restored.Primary())
+	}
+
+	if p, err := restored.Decrypt(c, nil); err != nil || string(p) != "test message" {
+		t.Fatalf("Restored keyring can not decrypt: %v", err)
+	}
+
+	master[0] ^= 1
+	if _, err := Unmarshal(data, master); err == nil {
+		t.Fatal("Wrong master key accepted")
+	}
+}
@@ -0,0 +1,99 @@
+package keyring
+
+import (
+	"encoding/binary"
+	"errors"
+	"github.com/GoKillers/libsodium-go/crypto/aead/xchacha20poly1305ietf"
+	"github.com/GoKillers/libsodium-go/randombytes"
+	"github.com/GoKillers/libsodium-go/sodium"
+	"github.com/GoKillers/libsodium-go/support"
+)
+
+// MasterKeyBytes is the size of the master key protecting a serialized keyring.
+const MasterKeyBytes = xchacha20poly1305ietf.KeyBytes
+
+// magic identifies a serialized keyring and the version of its format.
+const magic = "LSKR\x01"
+
+// Marshal serializes the keyring, encrypted with XChaCha20-Poly1305-IETF
+// under a master key `master`. The result can be restored with Unmarshal.
+func (kr *Keyring) Marshal(master *[MasterKeyBytes]byte) []byte {
+	support.NilPanic(master == nil, "master key")
+
+	kr.mu.RLock()
+	var payload []byte
+	payload = binary.BigEndian.AppendUint32(payload, uint32(kr.primary))
+	payload = binary.BigEndian.AppendUint32(payload, uint32(len(kr.keys)))
+	for id, k := range kr.keys {
+		payload = binary.BigEndian.AppendUint32(payload, uint32(id))
+		payload = append(payload, byte(k.alg), byte(len(k.raw)))
+		payload = append(payload, k.raw...)
+	}
+	kr.mu.RUnlock()
+	defer sodium.MemZero(payload)
+
+	nonce := new([xchacha20poly1305ietf.NonceBytes]byte)
+	randombytes.RandomBytesBuf(nonce[:])
+
+	out := append([]byte(magic), nonce[:]...)
+
+	return xchacha20poly1305ietf.Seal(out, payload, []byte(magic), nonce, master)
+}
+
+// Unmarshal restores a keyring serialized by Marshal, using the master key `master`.
+func Unmarshal(data []byte, master *[MasterKeyBytes]byte) (*Keyring, error) {
+	support.NilPanic(master == nil, "master key")
+
+	header := len(magic) + xchacha20poly1305ietf.NonceBytes
+	if len(data) < header+xchacha20poly1305ietf.ABytes || string(data[:len(magic)]) != magic {
+		return nil, errors.New("keyring: invalid serialized keyring")
+	}
+
+	nonce := (*[xchacha20poly1305ietf.NonceBytes]byte)(data[len(magic):header])
+
+	payload, err := xchacha20poly1305ietf.Open(nil, data[header:], []byte(magic), nonce, master)
+	if err != nil {
+		return nil, err
+	}
+	defer sodium.MemZero(payload)
+
+	invalid := errors.New("keyring: invalid serialized keyring")
+
+	if len(payload) < 8 {
+		return nil, invalid
+	}
+
+	kr := New()
+	primary := KeyID(binary.BigEndian.Uint32(payload))
+	count := binary.BigEndian.Uint32(payload[4:])
+	p := payload[8:]
+
+	for i := uint32(0); i < count; i++ {
+		if len(p) < 6 || len(p) < 6+int(p[5]) {
+			return nil, invalid
+		}
+
+		id := KeyID(binary.BigEndian.Uint32(p))
+		alg := Algorithm(p[4])
+		k := p[6 : 6+int(p[5])]
+
+		if err := kr.add(id, alg, k); err != nil {
+			return nil, err
+		}
+
+		p = p[6+len(k):]
+	}
+
+	if len(p) != 0 {
+		return nil, invalid
+	}
+
+	if count > 0 {
+		if _, ok := kr.keys[primary]; !ok {
+			return nil, invalid
+		}
+		kr.primary = primary
+	}
+
+	return kr, nil
+}
@@ -0,0 +1,189 @@
+package xchacha20poly1305ietf
+
+// #cgo pkg-config: libsodium
+// #include <stdlib.h>
+// #include <sodium.h>
+//
+// typedef struct {
+// 	size_t             nonce;
+// 	size_t             ad;
+// 	unsigned long long adlen;
+// 	size_t             data;
+// 	unsigned long long datalen;
+// 	int                result;
+// } batch_item;
+//
+// static void seal_batch(unsigned char *buf, batch_item *items, size_t n, const unsigned char *k)
+// {
+// 	size_t i;
+//
+// 	for (i = 0; i < n; i++) {
+// 		items[i].result = crypto_aead_xchacha20poly1305_ietf_encrypt(
+// 			buf + items[i].data, NULL, buf + items[i].data, items[i].datalen,
+// 			buf + items[i].ad, items[i].adlen, NULL, buf + items[i].nonce, k);
+// 	}
+// }
+//
+// static void open_batch(unsigned char *buf, batch_item *items, size_t n, const unsigned char *k)
+// {
+// 	size_t i;
+//
+// 	for (i = 0; i < n; i++) {
+// 		items[i].result = crypto_aead_xchacha20poly1305_ietf_decrypt(
+// 			buf + items[i].data, NULL, NULL, buf + items[i].data, items[i].datalen,
+// 			buf + items[i].ad, items[i].adlen, buf + items[i].nonce, k);
+// 	}
+// }
+import "C"
+import (
+	"github.com/GoKillers/libsodium-go/sodium"
+	"github.com/GoKillers/libsodium-go/support"
+	"sync"
+)
+
+// Message is an entry of a batch processed by SealBatch or OpenBatch.
+type Message struct {
+	Nonce *[NonceBytes]byte
+	In    []byte // Plaintext for SealBatch, ciphertext for OpenBatch
+	AD    []byte // Additional data
+	Out   []byte // Destination, the result is appended as with Seal and Open
+	Err   error  // Set by OpenBatch if the message could not be verified
+}
+
+// batch is the scratch space used to pass a batch of messages to C.
+// The nonces, additional data and inputs of all messages are copied into
+// a single buffer and referenced by offset, so that the batch contains
+// no Go pointers and is processed in place by a single cgo call.
+type batch struct {
+	buf   []byte
+	items []C.batch_item
+}
+
+var batchPool = sync.Pool{
+	New: func() interface{} { return new(batch) },
+}
+
+// newBatch copies a batch of messages into scratch space, reserving
+// `extra` bytes after each input for the output of the cipher.
+func newBatch(msgs []Message, extra int) *batch {
+	size := 0
+	for i := range msgs {
+		support.NilPanic(msgs[i].Nonce == nil, "nonce")
+		size += NonceBytes + len(msgs[i].AD) + len(msgs[i].In) + extra
+	}
+
+	b := batchPool.Get().(*batch)
+	if cap(b.buf) < size {
+		b.buf = make([]byte, size)
+	}
+	if cap(b.items) < len(msgs) {
+		b.items = make([]C.batch_item, len(msgs))
+	}
+	b.buf, b.items = b.buf[:size], b.items[:len(msgs)]
+
+	off := 0
+	for i := range msgs {
+		msg := &msgs[i]
+		item := &b.items[i]
+
+		item.nonce = C.size_t(off)
+		off += copy(b.buf[off:], msg.Nonce[:])
+
+		item.ad, item.adlen = C.size_t(off), C.ulonglong(len(msg.AD))
+		off += copy(b.buf[off:], msg.AD)
+
+		item.data, item.datalen = C.size_t(off), C.ulonglong(len(msg.In))
+		off += copy(b.buf[off:], msg.In) + extra
+	}
+
+	return b
+}
+
+// release zeroes the scratch space, which holds plaintext, and returns it to the pool.
+func (b *batch) release() {
+	sodium.MemZero(b.buf)
+	batchPool.Put(b)
+}
+
+// output returns the output of the cipher for message `i`.
+func (b *batch) output(i, n int) []byte {
+	return b.buf[b.items[i].data : int(b.items[i].data)+n]
+}
+
+// SealBatch encrypts a batch of messages with a secret key `k` using a single cgo call.
+// For each message, the result of Seal(msg.Out, msg.In, msg.AD, msg.Nonce, k)
+// is stored in msg.Out. The inputs are copied before encryption, so msg.Out
+// may overlap msg.In in any way.
+//
+// Batching saves the overhead of a cgo call per message, which matters most
+// for small messages. Each call still copies the inputs once.
+func SealBatch(msgs []Message, k *[KeyBytes]byte) {
+	support.NilPanic(k == nil, "secret key")
+
+	if len(msgs) == 0 {
+		return
+	}
+
+	b := newBatch(msgs, ABytes)
+	defer b.release()
+
+	C.seal_batch(
+		(*C.uchar)(support.BytePointer(b.buf)),
+		&b.items[0],
+		C.size_t(len(b.items)),
+		(*C.uchar)(&k[0]))
+
+	for i := range msgs {
+		msg := &msgs[i]
+
+		var c []byte
+		msg.Out, c = support.SliceForAppend(msg.Out, len(msg.In)+ABytes)
+		copy(c, b.output(i, len(c)))
+	}
+}
+
+// OpenBatch decrypts and verifies a batch of messages with a secret key `k` using a single cgo call.
+// For each message, the result of Open(msg.Out, msg.In, msg.AD, msg.Nonce, k)
+// is stored in msg.Out. The inputs are copied before decryption, so msg.Out
+// may overlap msg.In in any way.
+//
+// A message that can not be verified does not stop the batch: its Err is set
+// and its Out is left unchanged. If any message failed, a VerificationError is returned.
+func OpenBatch(msgs []Message, k *[KeyBytes]byte) error {
+	support.NilPanic(k == nil, "secret key")
+
+	if len(msgs) == 0 {
+		return nil
+	}
+
+	for i := range msgs {
+		support.CheckSizeMin(msgs[i].In, ABytes, "ciphertext")
+	}
+
+	b := newBatch(msgs, 0)
+	defer b.release()
+
+	C.open_batch(
+		(*C.uchar)(support.BytePointer(b.buf)),
+		&b.items[0],
+		C.size_t(len(b.items)),
+		(*C.uchar)(&k[0]))
+
+	var err error
+	for i := range msgs {
+		msg := &msgs[i]
+		msg.Err = nil
+
+		if b.items[i].result != 0 {
+			msg.Err = &support.VerificationError{}
+			err = msg.Err
+			continue
+		}
+
+		var m []byte
+		msg.Out, m = support.SliceForAppend(msg.Out, len(msg.In)-ABytes)
+		copy(m, b.output(i, len(m)))
+	}
+
+	return err
+}
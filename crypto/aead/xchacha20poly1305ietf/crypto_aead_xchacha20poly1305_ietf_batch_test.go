@@ -0,0 +1,138 @@
+package xchacha20poly1305ietf
+
+import (
+	"bytes"
+	"github.com/GoKillers/libsodium-go/randombytes"
+	"testing"
+)
+
+func TestSealOpenBatch(t *testing.T) {
+	k := GenerateKey()
+	msgs := make([]Message, 10)
+
+	for i := range msgs {
+		msgs[i].Nonce = new([NonceBytes]byte)
+		copy(msgs[i].Nonce[:], randombytes.RandomBytes(NonceBytes))
+		msgs[i].In = randombytes.RandomBytes(i * 100)
+		msgs[i].AD = randombytes.RandomBytes(i)
+		msgs[i].Out = []byte("dst")
+	}
+
+	SealBatch(msgs, k)
+
+	for i, msg := range msgs {
+		expected := Seal([]byte("dst"), msg.In, msg.AD, msg.Nonce, k)
+		if !bytes.Equal(msg.Out, expected) {
+			t.Fatalf("SealBatch differs from Seal for message %d", i)
+		}
+	}
+
+	// Decrypt in place, with a forged message
+	plaintexts := make([][]byte, len(msgs))
+	for i := range msgs {
+		plaintexts[i] = msgs[i].In
+		msgs[i].In = msgs[i].Out[len("dst"):]
+		msgs[i].Out = msgs[i].In[:0]
+	}
+	msgs[3].In[0] ^= 1
+	forged := append([]byte(nil), msgs[3].In...)
+
+	if err := OpenBatch(msgs, k); err == nil {
+		t.Fatal("OpenBatch accepted a forged message")
+	}
+
+	for i, msg := range msgs {
+		if i == 3 {
+			if msg.Err == nil || len(msg.Out) != 0 || !bytes.Equal(msg.In, forged) {
+				t.Fatal("Forged message not reported")
+			}
+			continue
+		}
+
+		if msg.Err != nil || !bytes.Equal(msg.Out, plaintexts[i]) {
+			t.Fatalf("OpenBatch failed for message %d: %v", i, msg.Err)
+		}
+	}
+}
+
+// benchmarkSizes are the packet sizes used by the batch benchmarks:
+// small packets, where the cgo overhead matters most, and the packets
+// of a typical UDP tunnel, where the cipher itself dominates.
+var benchmarkSizes = []struct {
+	name string
+	size int
+}{
+	{"64", 64},
+	{"1200", 1200},
+}
+
+// benchmarkPackets returns a batch of `n` sealed packets of `size` bytes
+// with their plaintexts in Out, ready to be used by SealBatch or OpenBatch.
+func benchmarkPackets(n, size int) ([]Message, *[KeyBytes]byte) {
+	k := GenerateKey()
+	msgs := make([]Message, n)
+
+	for i := range msgs {
+		msgs[i].Nonce = new([NonceBytes]byte)
+		msgs[i].In = Seal(nil, make([]byte, size), nil, msgs[i].Nonce, k)
+		msgs[i].Out = make([]byte, 0, size+ABytes)
+	}
+
+	return msgs, k
+}
+
+func benchmarkSeal(b *testing.B, batch bool) {
+	for _, bs := range benchmarkSizes {
+		b.Run(bs.name, func(b *testing.B) {
+			msgs, k := benchmarkPackets(64, bs.size)
+			for j := range msgs {
+				msgs[j].In = msgs[j].In[:bs.size]
+			}
+
+			b.ReportAllocs()
+			b.SetBytes(int64(len(msgs) * bs.size))
+			for i := 0; i < b.N; i++ {
+				if batch {
+					for j := range msgs {
+						msgs[j].Out = msgs[j].Out[:0]
+					}
+					SealBatch(msgs, k)
+				} else {
+					for j := range msgs {
+						msgs[j].Out = Seal(msgs[j].Out[:0], msgs[j].In, msgs[j].AD, msgs[j].Nonce, k)
+					}
+				}
+			}
+		})
+	}
+}
+
+func benchmarkOpen(b *testing.B, batch bool) {
+	for _, bs := range benchmarkSizes {
+		b.Run(bs.name, func(b *testing.B) {
+			msgs, k := benchmarkPackets(64, bs.size)
+
+			b.ReportAllocs()
+			b.SetBytes(int64(len(msgs) * bs.size))
+			for i := 0; i < b.N; i++ {
+				if batch {
+					for j := range msgs {
+						msgs[j].Out = msgs[j].Out[:0]
+					}
+					if err := OpenBatch(msgs, k); err != nil {
+						b.Fatal(err)
+					}
+				} else {
+					for j := range msgs {
+						msgs[j].Out, _ = Open(msgs[j].Out[:0], msgs[j].In, msgs[j].AD, msgs[j].Nonce, k)
+					}
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkSealPerCall(b *testing.B) { benchmarkSeal(b, false) }
+func BenchmarkSealBatch(b *testing.B)   { benchmarkSeal(b, true) }
+func BenchmarkOpenPerCall(b *testing.B) { benchmarkOpen(b, false) }
+func BenchmarkOpenBatch(b *testing.B)   { benchmarkOpen(b, true) }
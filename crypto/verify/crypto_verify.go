@@ -0,0 +1,48 @@
+// Package verify contains the libsodium bindings for constant-time comparison of fixed-size secrets.
+package verify
+
+// #cgo pkg-config: libsodium
+// #include <stdlib.h>
+// #include <sodium.h>
+import "C"
+import "github.com/GoKillers/libsodium-go/sodium"
+import "github.com/GoKillers/libsodium-go/support"
+
+// Sodium should always be initialised
+func init() {
+	sodium.Initialize()
+}
+
+// Sizes of the compared values.
+const (
+	Bytes16 int = C.crypto_verify_16_BYTES // Size of a value compared by Verify16
+	Bytes32 int = C.crypto_verify_32_BYTES // Size of a value compared by Verify32
+	Bytes64 int = C.crypto_verify_64_BYTES // Size of a value compared by Verify64
+)
+
+// Verify16 returns true if `x` and `y` are equal.
+// The comparison takes constant time.
+func Verify16(x, y *[Bytes16]byte) bool {
+	support.NilPanic(x == nil, "x")
+	support.NilPanic(y == nil, "y")
+
+	return C.crypto_verify_16((*C.uchar)(&x[0]), (*C.uchar)(&y[0])) == 0
+}
+
+// Verify32 returns true if `x` and `y` are equal.
+// The comparison takes constant time.
+func Verify32(x, y *[Bytes32]byte) bool {
+	support.NilPanic(x == nil, "x")
+	support.NilPanic(y == nil, "y")
+
+	return C.crypto_verify_32((*C.uchar)(&x[0]), (*C.uchar)(&y[0])) == 0
+}
+
+// Verify64 returns true if `x` and `y` are equal.
+// The comparison takes constant time.
+func Verify64(x, y *[Bytes64]byte) bool {
+	support.NilPanic(x == nil, "x")
+	support.NilPanic(y == nil, "y")
+
+	return C.crypto_verify_64((*C.uchar)(&x[0]), (*C.uchar)(&y[0])) == 0
+}
@@ -0,0 +1,21 @@
+package verify
+
+import "testing"
+
+func Test(t *testing.T) {
+	var a16, b16 [Bytes16]byte
+	var a32, b32 [Bytes32]byte
+	var a64, b64 [Bytes64]byte
+
+	if !Verify16(&a16, &b16) || !Verify32(&a32, &b32) || !Verify64(&a64, &b64) {
+		t.Fatal("Equal values did not verify")
+	}
+
+	b16[Bytes16-1] = 1
+	b32[Bytes32-1] = 1
+	b64[Bytes64-1] = 1
+
+	if Verify16(&a16, &b16) || Verify32(&a32, &b32) || Verify64(&a64, &b64) {
+		t.Fatal("Different values unexpectedly verified")
+	}
+}
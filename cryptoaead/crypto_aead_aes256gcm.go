@@ -5,12 +5,17 @@ package cryptoaead
 // #include <sodium.h>
 import "C"
 import (
+	"github.com/GoKillers/libsodium-go/sodium"
 	"github.com/GoKillers/libsodium-go/support"
 	"unsafe"
 )
 
+// Sodium should always be initialised
+func init() {
+	sodium.Initialize()
+}
+
 func CryptoAEADAES256GCMIsAvailable() bool {
-	C.sodium_init()
 	return int(C.crypto_aead_aes256gcm_is_available()) != 0
 }
 
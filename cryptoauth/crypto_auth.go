@@ -4,8 +4,14 @@ package cryptoauth
 // #include <stdlib.h>
 // #include <sodium.h>
 import "C"
+import "github.com/GoKillers/libsodium-go/sodium"
 import "github.com/GoKillers/libsodium-go/support"
 
+// Sodium should always be initialised
+func init() {
+	sodium.Initialize()
+}
+
 func CryptoAuthBytes() int {
 	return int(C.crypto_auth_bytes())
 }
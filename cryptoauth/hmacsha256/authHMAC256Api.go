@@ -4,6 +4,12 @@ package authhmac256api
 // #include <stdlib.h>
 // #include <sodium.h>
 import "C"
+import "github.com/GoKillers/libsodium-go/sodium"
+
+// Sodium should always be initialised
+func init() {
+	sodium.Initialize()
+}
 
 func CryptoAuthHMAC256Bytes() int {
 	return int(C.crypto_auth_hmacsha256_bytes())
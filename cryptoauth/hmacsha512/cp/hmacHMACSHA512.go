@@ -4,6 +4,12 @@ package hmachmacsha512
 // #include <stdlib.h>
 // #include <sodium.h>
 import "C"
+import "github.com/GoKillers/libsodium-go/sodium"
+
+// Sodium should always be initialised
+func init() {
+	sodium.Initialize()
+}
 
 func CryptoAuthHMACSHA512Init(state *C.struct_crypto_auth_hmacsha512_state, key []byte, keylen int) (*C.struct_crypto_auth_hmacsha512_state, int) {
 	exit := int(C.crypto_auth_hmacsha512_init(
@@ -4,8 +4,14 @@ package cryptobox
 // #include <stdlib.h>
 // #include <sodium.h>
 import "C"
+import "github.com/GoKillers/libsodium-go/sodium"
 import "github.com/GoKillers/libsodium-go/support"
 
+// Sodium should always be initialised
+func init() {
+	sodium.Initialize()
+}
+
 func CryptoBoxSeedBytes() int {
 	return int(C.crypto_box_seedbytes())
 }
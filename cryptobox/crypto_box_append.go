@@ -0,0 +1,163 @@
+package cryptobox
+
+// #cgo pkg-config: libsodium
+// #include <stdlib.h>
+// #include <sodium.h>
+import "C"
+import (
+	"errors"
+	"github.com/GoKillers/libsodium-go/support"
+)
+
+// Seal encrypts and authenticates a message `m` using a nonce `n`, the recipient's public key `pk`
+// and the sender's secret key `sk`, and appends the ciphertext to `dst`, returning the updated slice.
+// No memory is allocated if `dst` has enough capacity.
+// An error is returned if the public key is invalid.
+//
+// The message and dst may alias exactly or not at all. To reuse
+// the storage of the message for the ciphertext, use m[:0] as dst.
+func Seal(dst, m, n, pk, sk []byte) ([]byte, error) {
+	support.CheckSize(n, CryptoBoxNonceBytes(), "nonce")
+	support.CheckSize(pk, CryptoBoxPublicKeyBytes(), "public key")
+	support.CheckSize(sk, CryptoBoxSecretKeyBytes(), "secret key")
+
+	ret, c := support.SliceForAppend(dst, len(m)+CryptoBoxMacBytes())
+
+	exit := C.crypto_box_easy(
+		(*C.uchar)(&c[0]),
+		(*C.uchar)(support.BytePointer(m)),
+		(C.ulonglong)(len(m)),
+		(*C.uchar)(&n[0]),
+		(*C.uchar)(&pk[0]),
+		(*C.uchar)(&sk[0]))
+
+	if exit != 0 {
+		return nil, errors.New("cryptobox: invalid public key")
+	}
+
+	return ret, nil
+}
+
+// Open verifies and decrypts a ciphertext `c` using a nonce `n`, the sender's public key `pk`
+// and the recipient's secret key `sk`, and appends the message to `dst`, returning the updated slice.
+// No memory is allocated if `dst` has enough capacity.
+//
+// The ciphertext and dst may alias exactly or not at all. To reuse
+// the storage of the ciphertext for the message, use c[:0] as dst.
+// Even if the function fails, the contents of dst, up to its capacity,
+// may be overwritten.
+func Open(dst, c, n, pk, sk []byte) ([]byte, error) {
+	support.CheckSizeMin(c, CryptoBoxMacBytes(), "ciphertext")
+	support.CheckSize(n, CryptoBoxNonceBytes(), "nonce")
+	support.CheckSize(pk, CryptoBoxPublicKeyBytes(), "public key")
+	support.CheckSize(sk, CryptoBoxSecretKeyBytes(), "secret key")
+
+	ret, m := support.SliceForAppend(dst, len(c)-CryptoBoxMacBytes())
+
+	exit := C.crypto_box_open_easy(
+		(*C.uchar)(support.BytePointer(m)),
+		(*C.uchar)(&c[0]),
+		(C.ulonglong)(len(c)),
+		(*C.uchar)(&n[0]),
+		(*C.uchar)(&pk[0]),
+		(*C.uchar)(&sk[0]))
+
+	if exit != 0 {
+		return nil, &support.VerificationError{}
+	}
+
+	return ret, nil
+}
+
+// SealAfterNm encrypts and authenticates a message `m` using a nonce `n` and a shared key `k`
+// computed by CryptoBoxBeforeNm, and appends the ciphertext to `dst`, returning the updated slice.
+// No memory is allocated if `dst` has enough capacity.
+// See Seal for the aliasing rules.
+func SealAfterNm(dst, m, n, k []byte) []byte {
+	support.CheckSize(n, CryptoBoxNonceBytes(), "nonce")
+	support.CheckSize(k, CryptoBoxBeforeNmBytes(), "shared secret key")
+
+	ret, c := support.SliceForAppend(dst, len(m)+CryptoBoxMacBytes())
+
+	C.crypto_box_easy_afternm(
+		(*C.uchar)(&c[0]),
+		(*C.uchar)(support.BytePointer(m)),
+		(C.ulonglong)(len(m)),
+		(*C.uchar)(&n[0]),
+		(*C.uchar)(&k[0]))
+
+	return ret
+}
+
+// OpenAfterNm verifies and decrypts a ciphertext `c` using a nonce `n` and a shared key `k`
+// computed by CryptoBoxBeforeNm, and appends the message to `dst`, returning the updated slice.
+// No memory is allocated if `dst` has enough capacity.
+// See Open for the aliasing rules.
+func OpenAfterNm(dst, c, n, k []byte) ([]byte, error) {
+	support.CheckSizeMin(c, CryptoBoxMacBytes(), "ciphertext")
+	support.CheckSize(n, CryptoBoxNonceBytes(), "nonce")
+	support.CheckSize(k, CryptoBoxBeforeNmBytes(), "shared secret key")
+
+	ret, m := support.SliceForAppend(dst, len(c)-CryptoBoxMacBytes())
+
+	exit := C.crypto_box_open_easy_afternm(
+		(*C.uchar)(support.BytePointer(m)),
+		(*C.uchar)(&c[0]),
+		(C.ulonglong)(len(c)),
+		(*C.uchar)(&n[0]),
+		(*C.uchar)(&k[0]))
+
+	if exit != 0 {
+		return nil, &support.VerificationError{}
+	}
+
+	return ret, nil
+}
+
+// SealAnonymous encrypts a message `m` for the recipient's public key `pk` like CryptoBoxSeal,
+// and appends the ciphertext to `dst`, returning the updated slice.
+// No memory is allocated if `dst` has enough capacity.
+// An error is returned if the public key is invalid.
+// The message and dst must not overlap.
+func SealAnonymous(dst, m, pk []byte) ([]byte, error) {
+	support.CheckSize(pk, CryptoBoxPublicKeyBytes(), "public key")
+
+	ret, c := support.SliceForAppend(dst, len(m)+CryptoBoxSealBytes())
+
+	exit := C.crypto_box_seal(
+		(*C.uchar)(&c[0]),
+		(*C.uchar)(support.BytePointer(m)),
+		(C.ulonglong)(len(m)),
+		(*C.uchar)(&pk[0]))
+
+	if exit != 0 {
+		return nil, errors.New("cryptobox: invalid public key")
+	}
+
+	return ret, nil
+}
+
+// OpenAnonymous decrypts a ciphertext `c` created by SealAnonymous or CryptoBoxSeal using
+// the recipient's key pair, and appends the message to `dst`, returning the updated slice.
+// No memory is allocated if `dst` has enough capacity.
+// The ciphertext and dst must not overlap.
+func OpenAnonymous(dst, c, pk, sk []byte) ([]byte, error) {
+	support.CheckSizeMin(c, CryptoBoxSealBytes(), "ciphertext")
+	support.CheckSize(pk, CryptoBoxPublicKeyBytes(), "public key")
+	support.CheckSize(sk, CryptoBoxSecretKeyBytes(), "secret key")
+
+	ret, m := support.SliceForAppend(dst, len(c)-CryptoBoxSealBytes())
+
+	exit := C.crypto_box_seal_open(
+		(*C.uchar)(support.BytePointer(m)),
+		(*C.uchar)(&c[0]),
+		(C.ulonglong)(len(c)),
+		(*C.uchar)(&pk[0]),
+		(*C.uchar)(&sk[0]))
+
+	if exit != 0 {
+		return nil, &support.VerificationError{}
+	}
+
+	return ret, nil
+}
@@ -0,0 +1,102 @@
+package cryptobox
+
+import (
+	"bytes"
+	"github.com/GoKillers/libsodium-go/randombytes"
+	"testing"
+)
+
+func TestSealOpen(t *testing.T) {
+	sk1, pk1, _ := CryptoBoxKeyPair()
+	sk2, pk2, _ := CryptoBoxKeyPair()
+	n := randombytes.RandomBytes(CryptoBoxNonceBytes())
+	m := []byte("test message")
+	prefix := []byte("prefix")
+
+	c, err := Seal(append([]byte(nil), prefix...), m, n, pk2, sk1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(c[:len(prefix)], prefix) {
+		t.Fatal("Seal overwrote dst")
+	}
+
+	expected, _ := CryptoBoxEasy(m, n, pk2, sk1)
+	if !bytes.Equal(c[len(prefix):], expected) {
+		t.Fatal("Seal differs from CryptoBoxEasy")
+	}
+
+	p, err := Open(nil, c[len(prefix):], n, pk1, sk2)
+	if err != nil || !bytes.Equal(p, m) {
+		t.Fatalf("Open failed: %v", err)
+	}
+
+	// Shared key variants, decrypting in place
+	k, _ := CryptoBoxBeforeNm(pk1, sk2)
+	c = SealAfterNm(nil, m, n, k)
+	p, err = OpenAfterNm(c[:0], c, n, k)
+	if err != nil || !bytes.Equal(p, m) {
+		t.Fatalf("OpenAfterNm failed: %v", err)
+	}
+
+	c = SealAfterNm(nil, m, n, k)
+	c[0] ^= 1
+	if _, err = Open(nil, c, n, pk1, sk2); err == nil {
+		t.Fatal("Open accepted a forged ciphertext")
+	}
+}
+
+func TestSealOpenAnonymous(t *testing.T) {
+	sk, pk, _ := CryptoBoxKeyPair()
+	m := []byte("test message")
+
+	c, err := SealAnonymous(nil, m, pk)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := OpenAnonymous(make([]byte, 0, len(m)), c, pk, sk)
+	if err != nil || !bytes.Equal(p, m) {
+		t.Fatalf("OpenAnonymous failed: %v", err)
+	}
+
+	p, _ = CryptoBoxSealOpen(c, pk, sk)
+	if !bytes.Equal(p, m) {
+		t.Fatal("CryptoBoxSealOpen can not open SealAnonymous output")
+	}
+}
+
+func BenchmarkSealAfterNm(b *testing.B) {
+	sk, pk, _ := CryptoBoxKeyPair()
+	k, _ := CryptoBoxBeforeNm(pk, sk)
+	n := make([]byte, CryptoBoxNonceBytes())
+	m := make([]byte, 1024)
+	buf := make([]byte, 0, len(m)+CryptoBoxMacBytes())
+
+	b.SetBytes(int64(len(m)))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		SealAfterNm(buf, m, n, k)
+	}
+}
+
+func BenchmarkOpenAfterNm(b *testing.B) {
+	sk, pk, _ := CryptoBoxKeyPair()
+	k, _ := CryptoBoxBeforeNm(pk, sk)
+	n := make([]byte, CryptoBoxNonceBytes())
+	c := SealAfterNm(nil, make([]byte, 1024), n, k)
+	buf := make([]byte, 0, len(c))
+
+	b.SetBytes(int64(len(c) - CryptoBoxMacBytes()))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := OpenAfterNm(buf, c, n, k); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
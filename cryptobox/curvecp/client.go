@@ -0,0 +1,118 @@
+package curvecp
+
+import (
+	"bytes"
+	"errors"
+	"github.com/GoKillers/libsodium-go/cryptobox"
+	"github.com/GoKillers/libsodium-go/randombytes"
+	"github.com/GoKillers/libsodium-go/support"
+)
+
+// ClientConfig configures a client.
+type ClientConfig struct {
+	PublicKey       []byte    // Long-term public key of the client
+	SecretKey       []byte    // Long-term secret key of the client
+	Extension       Extension // Extension of the client
+	ServerPublicKey []byte    // Long-term public key of the server
+	ServerExtension Extension // Extension of the server
+	ServerName      string    // Domain name of the server, may be empty
+}
+
+// Client performs the client side of a handshake from a fresh short-term key.
+type Client struct {
+	config ClientConfig
+	name   [nameBytes]byte
+
+	shortPK, shortSK []byte
+	key              []byte // Shared key of the short-term key and the server's long-term key
+	nonce            uint64
+	initiated        bool
+}
+
+// NewClient returns a client for a configuration.
+func NewClient(config *ClientConfig) (*Client, error) {
+	support.CheckSize(config.PublicKey, PublicKeyBytes, "public key")
+	support.CheckSize(config.SecretKey, SecretKeyBytes, "secret key")
+	support.CheckSize(config.ServerPublicKey, PublicKeyBytes, "server public key")
+
+	name, err := encodeName(config.ServerName)
+	if err != nil {
+		return nil, err
+	}
+
+	sk, pk, _ := cryptobox.CryptoBoxKeyPair()
+
+	key, err := beforeNm(config.ServerPublicKey, sk)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{config: *config, name: name, shortPK: pk, shortSK: sk, key: key}, nil
+}
+
+// Hello returns a Hello packet. It may be called again to retransmit.
+func (c *Client) Hello() []byte {
+	c.nonce++
+
+	out := make([]byte, 0, helloBytes)
+	out = appendHeader(out, helloMagic, &c.config.ServerExtension, &c.config.Extension)
+	out = append(out, c.shortPK...)
+	out = append(out, make([]byte, 64)...)
+	out = appendNonce(out, c.nonce)
+
+	return cryptobox.SealAfterNm(out, make([]byte, 64), nonce(helloNonce, out[136:]), c.key)
+}
+
+// Initiate verifies a Cookie packet and returns the session it starts together
+// with an Initiate packet that carries `message`. Until the server answers,
+// further messages are sent in Initiate packets by Session.Seal.
+// A client starts only one session.
+func (c *Client) Initiate(cookie, message []byte) (*Session, []byte, error) {
+	if c.initiated {
+		return nil, nil, errors.New("curvecp: session already initiated")
+	}
+
+	if !checkHeader(cookie, cookieMagic, cookiePacketBytes, cookiePacketBytes, &c.config.Extension) ||
+		!bytes.Equal(cookie[24:40], c.config.ServerExtension[:]) {
+		return nil, nil, errInvalidPacket
+	}
+
+	box, err := cryptobox.OpenAfterNm(nil, cookie[56:], nonce(cookieNonce, cookie[40:56]), c.key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	k, err := beforeNm(box[:PublicKeyBytes], c.shortSK)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	vouch := make([]byte, 0, vouchBytes)
+	vouch = append(vouch, c.config.PublicKey...)
+	vouch = append(vouch, randombytes.RandomBytes(16)...)
+	vouch, err = cryptobox.Seal(vouch, c.shortPK, nonce(vouchNonce, vouch[32:48]), c.config.ServerPublicKey, c.config.SecretKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	vouch = append(vouch, c.name[:]...)
+
+	session := &Session{
+		client:      true,
+		key:         k,
+		serverExt:   c.config.ServerExtension,
+		clientExt:   c.config.Extension,
+		clientShort: c.shortPK,
+		remote:      append([]byte(nil), c.config.ServerPublicKey...),
+		sendNonce:   c.nonce,
+		cookie:      box[PublicKeyBytes:],
+		vouch:       vouch,
+	}
+
+	p, err := session.Seal(message)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	c.initiated = true
+	return session, p, nil
+}
@@ -0,0 +1,186 @@
+// Package curvecp implements the cryptographic handshake and packet formats of
+// CurveCP on top of cryptobox.
+//
+// A client that knows the long-term public key of a server sends a Hello packet
+// from a fresh short-term key. The server answers with a Cookie packet holding
+// its own short-term public key and a cookie: both short-term keys, encrypted
+// under a minute key that only the server knows, so that the server keeps no
+// state until the client proves it received the cookie. The client then sends
+// Initiate packets with the cookie, its long-term public key and a vouch (a box
+// of its short-term public key from its long-term key to the server's), together
+// with its first messages. Once the client receives a server Message packet,
+// both sides exchange Message packets boxed between the short-term keys.
+//
+// Minute keys are replaced every MinuteKeyInterval, and cookies remain valid
+// until the minute key that encrypted them is replaced a second time. Every
+// packet from a short-term key uses the next value of a counter as its nonce,
+// and packets with a nonce that is not larger than the last accepted one are
+// rejected.
+//
+// The package implements the packets and the handshake, not the CurveCP message
+// protocol for reliable streams, so messages are passed on as they are.
+package curvecp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"github.com/GoKillers/libsodium-go/cryptobox"
+	"strings"
+	"time"
+)
+
+// Sizes and limits.
+const (
+	PublicKeyBytes = 32 // Size of a public key
+	SecretKeyBytes = 32 // Size of a secret key
+	ExtensionBytes = 16 // Size of an extension
+
+	MaxInitiateMessage = 640  // Maximum size of a message in an Initiate packet
+	MaxClientMessage   = 1088 // Maximum size of a message in a client Message packet
+	MaxServerMessage   = 1024 // Maximum size of a message in a server Message packet
+)
+
+// MinuteKeyInterval is the time after which a server replaces its minute key.
+const MinuteKeyInterval = time.Minute
+
+// Packet magics.
+const (
+	helloMagic         = "QvnQ5XlH"
+	cookieMagic        = "RL3aNMXK"
+	initiateMagic      = "QvnQ5XlI"
+	serverMessageMagic = "RL3aNMXM"
+	clientMessageMagic = "QvnQ5XlM"
+)
+
+// Nonce prefixes.
+const (
+	helloNonce         = "CurveCP-client-H"
+	cookieNonce        = "CurveCPK"
+	initiateNonce      = "CurveCP-client-I"
+	vouchNonce         = "CurveCPV"
+	minuteNonce        = "minute-k"
+	clientMessageNonce = "CurveCP-client-M"
+	serverMessageNonce = "CurveCP-server-M"
+)
+
+const (
+	boxMacBytes = 16  // Size of a box authentication tag
+	nonceBytes  = 8   // Size of a packet nonce
+	nameBytes   = 256 // Size of an encoded domain name
+	cookieBytes = 16 + 2*PublicKeyBytes + boxMacBytes
+
+	helloBytes          = 8 + 2*ExtensionBytes + PublicKeyBytes + 64 + nonceBytes + 64 + boxMacBytes
+	cookiePacketBytes   = 8 + 2*ExtensionBytes + 16 + PublicKeyBytes + cookieBytes + boxMacBytes
+	initiateHeaderBytes = 8 + 2*ExtensionBytes + PublicKeyBytes + cookieBytes + nonceBytes
+	vouchBytes          = PublicKeyBytes + 16 + PublicKeyBytes + boxMacBytes + nameBytes // Initiate box contents before the message
+	initiateBytes       = initiateHeaderBytes + vouchBytes + boxMacBytes                 // Initiate packet without a message
+	serverMessageBytes  = 8 + 2*ExtensionBytes + nonceBytes + boxMacBytes
+	clientMessageBytes  = 8 + 2*ExtensionBytes + PublicKeyBytes + nonceBytes + boxMacBytes
+)
+
+// Extension identifies a client or server beyond its address, for example one
+// of several servers sharing an address.
+type Extension [ExtensionBytes]byte
+
+// PacketType is the type of a packet.
+type PacketType byte
+
+// Packet types.
+const (
+	Hello PacketType = iota + 1
+	Cookie
+	Initiate
+	ServerMessage
+	ClientMessage
+)
+
+// TypeOf returns the type of a packet according to its magic, or zero if it is
+// not a CurveCP packet. The packet is not validated otherwise.
+func TypeOf(p []byte) PacketType {
+	if len(p) < 8 {
+		return 0
+	}
+
+	switch string(p[:8]) {
+	case helloMagic:
+		return Hello
+	case cookieMagic:
+		return Cookie
+	case initiateMagic:
+		return Initiate
+	case serverMessageMagic:
+		return ServerMessage
+	case clientMessageMagic:
+		return ClientMessage
+	}
+
+	return 0
+}
+
+// ErrMessageTooLarge is returned when a message does not fit in a packet.
+var ErrMessageTooLarge = errors.New("curvecp: message too large")
+
+var (
+	errInvalidPacket = errors.New("curvecp: invalid packet")
+	errReplay        = errors.New("curvecp: replayed packet")
+)
+
+// nonce returns a box nonce consisting of a prefix and the nonce of a packet.
+func nonce(prefix string, n []byte) []byte {
+	return append([]byte(prefix), n...)
+}
+
+// beforeNm returns the shared key of a public key and a secret key.
+func beforeNm(pk, sk []byte) ([]byte, error) {
+	k, exit := cryptobox.CryptoBoxBeforeNm(pk, sk)
+	if exit != 0 {
+		return nil, errInvalidPacket
+	}
+	return k, nil
+}
+
+// checkHeader checks the size, magic and extensions of a packet. The first
+// extension is the one of the receiver.
+func checkHeader(p []byte, magic string, min, max int, ext *Extension) bool {
+	return len(p) >= min && len(p) <= max && string(p[:8]) == magic &&
+		bytes.Equal(p[8:8+ExtensionBytes], ext[:])
+}
+
+// appendHeader appends a magic and two extensions to a packet.
+func appendHeader(dst []byte, magic string, to, from *Extension) []byte {
+	dst = append(dst, magic...)
+	dst = append(dst, to[:]...)
+	return append(dst, from[:]...)
+}
+
+// appendNonce appends the little-endian packet nonce `n`.
+func appendNonce(dst []byte, n uint64) []byte {
+	return binary.LittleEndian.AppendUint64(dst, n)
+}
+
+// encodeName encodes a domain name in the format of DNS, padded with zeros.
+// The empty name is encoded as all zeros.
+func encodeName(name string) ([nameBytes]byte, error) {
+	var out [nameBytes]byte
+
+	name = strings.TrimSuffix(name, ".")
+	if name == "" {
+		return out, nil
+	}
+
+	b := out[:0]
+	for _, label := range strings.Split(name, ".") {
+		if len(label) < 1 || len(label) > 63 {
+			return out, errors.New("curvecp: invalid server name")
+		}
+		b = append(b, byte(len(label)))
+		b = append(b, label...)
+	}
+
+	if len(b) >= nameBytes {
+		return out, errors.New("curvecp: invalid server name")
+	}
+
+	return out, nil
+}
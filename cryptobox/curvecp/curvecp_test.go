@@ -0,0 +1,459 @@
+package curvecp
+
+import (
+	"bytes"
+	"github.com/GoKillers/libsodium-go/cryptobox"
+	"github.com/GoKillers/libsodium-go/support"
+	"testing"
+	"time"
+)
+
+func testConfigs() (*ServerConfig, *ClientConfig) {
+	ssk, spk, _ := cryptobox.CryptoBoxKeyPair()
+	csk, cpk, _ := cryptobox.CryptoBoxKeyPair()
+
+	server := &ServerConfig{
+		PublicKey: spk,
+		SecretKey: ssk,
+		Extension: Extension{1, 2, 3},
+		Name:      "example.com",
+	}
+
+	client := &ClientConfig{
+		PublicKey:       cpk,
+		SecretKey:       csk,
+		Extension:       Extension{4, 5, 6},
+		ServerPublicKey: spk,
+		ServerExtension: server.Extension,
+		ServerName:      "example.com.",
+	}
+
+	return server, client
+}
+
+// handshake runs a handshake and returns both sessions.
+func handshake(t *testing.T, server *Server, config *ClientConfig) (*Session, *Session) {
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hello := client.Hello()
+	if len(hello) != helloBytes || TypeOf(hello) != Hello {
+		t.Fatal("invalid hello packet")
+	}
+
+	cookie, err := server.HandleHello(hello)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cookie) != cookiePacketBytes || TypeOf(cookie) != Cookie {
+		t.Fatal("invalid cookie packet")
+	}
+
+	cs, initiate, err := client.Initiate(cookie, []byte("initiate"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(initiate) != initiateBytes+8 || TypeOf(initiate) != Initiate {
+		t.Fatal("invalid initiate packet")
+	}
+
+	ss, m, err := server.HandleInitiate(initiate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(m) != "initiate" {
+		t.Fatalf("incorrect message %q", m)
+	}
+
+	return cs, ss
+}
+
+func TestHandshake(t *testing.T) {
+	sc, cc := testConfigs()
+	server, err := NewServer(sc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cs, ss := handshake(t, server, cc)
+
+	if !bytes.Equal(ss.RemotePublicKey(), cc.PublicKey) || !bytes.Equal(cs.RemotePublicKey(), sc.PublicKey) {
+		t.Fatal("incorrect remote public keys")
+	}
+
+	// Until the server answers, the client sends Initiate packets
+	p, _ := cs.Seal([]byte("second"))
+	if TypeOf(p) != Initiate {
+		t.Fatal("expected an initiate packet")
+	}
+	if m, err := ss.Open(p); err != nil || string(m) != "second" {
+		t.Fatal(m, err)
+	}
+
+	p, _ = ss.Seal([]byte("reply"))
+	if TypeOf(p) != ServerMessage {
+		t.Fatal("expected a server message packet")
+	}
+	if m, err := cs.Open(p); err != nil || string(m) != "reply" {
+		t.Fatal(m, err)
+	}
+
+	p, _ = cs.Seal([]byte("third"))
+	if TypeOf(p) != ClientMessage || len(p) != clientMessageBytes+5 {
+		t.Fatal("expected a client message packet")
+	}
+	if m, err := ss.Open(p); err != nil || string(m) != "third" {
+		t.Fatal(m, err)
+	}
+
+	// Replayed packets are rejected
+	if _, err := ss.Open(p); err != errReplay {
+		t.Fatalf("expected replay error, got %v", err)
+	}
+}
+
+func TestReplayedInitiate(t *testing.T) {
+	sc, cc := testConfigs()
+	server, _ := NewServer(sc)
+
+	client, _ := NewClient(cc)
+	cookie, _ := server.HandleHello(client.Hello())
+	cs, initiate, _ := client.Initiate(cookie, []byte("first"))
+
+	ss, m, err := server.HandleInitiate(initiate)
+	if err != nil || string(m) != "first" {
+		t.Fatal(m, err)
+	}
+
+	if _, _, err := server.HandleInitiate(initiate); err != errReplay {
+		t.Fatalf("expected replay error, got %v", err)
+	}
+
+	// A repeated Initiate packet goes to the session already started
+	p, _ := cs.Seal([]byte("second"))
+	if s, m, err := server.HandleInitiate(p); err != nil || s != ss || string(m) != "second" {
+		t.Fatal(m, err)
+	}
+
+	for _, p := range [][]byte{initiate, p} {
+		if _, err := ss.Open(p); err != errReplay {
+			t.Fatalf("expected replay error, got %v", err)
+		}
+	}
+
+	// Sessions are forgotten with the minute key of their cookie, after which
+	// their Initiate packets are rejected as expired
+	server.RotateMinuteKey()
+	server.RotateMinuteKey()
+	if _, _, err := server.HandleInitiate(initiate); err == nil || err == errReplay {
+		t.Fatalf("expected expired cookie, got %v", err)
+	}
+
+	// Accept ignores replayed Initiate packets
+	a, b := NewLoopback()
+	defer a.Close()
+
+	accepted := make(chan []byte, 1)
+	go func() {
+		_, m, _ := Accept(b, server)
+		accepted <- m
+	}()
+
+	client, _ = NewClient(cc)
+	cookie, _ = server.HandleHello(client.Hello())
+	_, initiate, _ = client.Initiate(cookie, []byte("first"))
+	if _, _, err := server.HandleInitiate(initiate); err != nil {
+		t.Fatal(err)
+	}
+
+	a.Send(initiate)
+	if _, err := Dial(a, cc, []byte("new")); err != nil {
+		t.Fatal(err)
+	}
+
+	if m := <-accepted; string(m) != "new" {
+		t.Fatalf("accepted %q", m)
+	}
+}
+
+func TestMinuteKeyRotation(t *testing.T) {
+	sc, cc := testConfigs()
+	server, _ := NewServer(sc)
+
+	now := time.Now()
+	server.now = func() time.Time { return now }
+	server.rotated = now
+
+	for _, step := range []struct {
+		elapsed time.Duration
+		valid   bool
+	}{
+		{0, true},
+		{MinuteKeyInterval - time.Second, true},
+		{MinuteKeyInterval, true},
+		{2 * MinuteKeyInterval, false},
+		{3*MinuteKeyInterval - time.Second, false},
+	} {
+		client, _ := NewClient(cc)
+		cookie, err := server.HandleHello(client.Hello())
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, initiate, _ := client.Initiate(cookie, nil)
+
+		now = now.Add(step.elapsed)
+
+		_, _, err = server.HandleInitiate(initiate)
+		if (err == nil) != step.valid {
+			t.Errorf("cookie after %v: %v", step.elapsed, err)
+		}
+	}
+
+	client, _ := NewClient(cc)
+	cookie, _ := server.HandleHello(client.Hello())
+	_, initiate, _ := client.Initiate(cookie, nil)
+
+	server.RotateMinuteKey()
+	if _, _, err := server.HandleInitiate(initiate); err != nil {
+		t.Error(err)
+	}
+
+	server.RotateMinuteKey()
+	if _, _, err := server.HandleInitiate(initiate); err == nil {
+		t.Error("cookie accepted after two rotations")
+	}
+}
+
+func TestMinuteKeyCopies(t *testing.T) {
+	sc, _ := testConfigs()
+	server, _ := NewServer(sc)
+
+	cur, prev, _ := server.minuteKeys()
+	want := append(append([]byte(nil), cur...), prev...)
+
+	// Rotations zero the keys of the server, but not the copies in use
+	server.RotateMinuteKey()
+	server.RotateMinuteKey()
+
+	if !bytes.Equal(append(cur, prev...), want) {
+		t.Fatal("minute keys in use changed by a rotation")
+	}
+}
+
+func TestTampered(t *testing.T) {
+	sc, cc := testConfigs()
+	server, _ := NewServer(sc)
+
+	client, _ := NewClient(cc)
+	hello := client.Hello()
+	cookie, _ := server.HandleHello(hello)
+
+	for i := range hello {
+		if i >= 24 && i < 40 || i >= 72 && i < 136 {
+			continue // The client extension and the zero padding are not authenticated
+		}
+
+		p := append([]byte(nil), hello...)
+		p[i] ^= 1
+		if _, err := server.HandleHello(p); err == nil {
+			t.Fatalf("hello: byte %d: tampered packet accepted", i)
+		}
+	}
+
+	for i := range cookie {
+		p := append([]byte(nil), cookie...)
+		p[i] ^= 1
+		if _, _, err := client.Initiate(p, nil); err == nil {
+			t.Fatalf("cookie: byte %d: tampered packet accepted", i)
+		}
+	}
+
+	_, initiate, err := client.Initiate(cookie, []byte("message"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := range initiate {
+		if i >= 24 && i < 40 {
+			continue // The client extension is not authenticated
+		}
+
+		p := append([]byte(nil), initiate...)
+		p[i] ^= 1
+		if _, _, err := server.HandleInitiate(p); err == nil {
+			t.Fatalf("initiate: byte %d: tampered packet accepted", i)
+		}
+	}
+
+	cs, ss := handshake(t, server, cc)
+	p, _ := ss.Seal([]byte("message"))
+	for i := range p {
+		q := append([]byte(nil), p...)
+		q[i] ^= 1
+		if _, err := cs.Open(q); err == nil {
+			t.Fatalf("message: byte %d: tampered packet accepted", i)
+		}
+	}
+}
+
+func TestVerification(t *testing.T) {
+	sc, cc := testConfigs()
+	server, _ := NewServer(sc)
+
+	// A client with the wrong server key
+	other, _ := testConfigs()
+	wrong := *cc
+	wrong.ServerPublicKey = other.PublicKey
+
+	client, _ := NewClient(&wrong)
+	if _, err := server.HandleHello(client.Hello()); !isVerificationError(err) {
+		t.Errorf("wrong server key: expected verification error, got %v", err)
+	}
+
+	// A client with the wrong server name
+	wrong = *cc
+	wrong.ServerName = "example.org"
+
+	client, _ = NewClient(&wrong)
+	cookie, _ := server.HandleHello(client.Hello())
+	_, initiate, _ := client.Initiate(cookie, nil)
+	if _, _, err := server.HandleInitiate(initiate); err == nil {
+		t.Error("wrong server name accepted")
+	}
+
+	// A client vouching with a key pair that does not match its public key
+	wrong = *cc
+	wrong.PublicKey = other.PublicKey
+
+	client, _ = NewClient(&wrong)
+	cookie, _ = server.HandleHello(client.Hello())
+	_, initiate, _ = client.Initiate(cookie, nil)
+	if _, _, err := server.HandleInitiate(initiate); !isVerificationError(err) {
+		t.Errorf("wrong vouch: expected verification error, got %v", err)
+	}
+
+	// A client for another server extension
+	wrong = *cc
+	wrong.ServerExtension = Extension{9}
+
+	client, _ = NewClient(&wrong)
+	if _, err := server.HandleHello(client.Hello()); err != errInvalidPacket {
+		t.Errorf("wrong extension: expected invalid packet, got %v", err)
+	}
+
+	// The cookie of another client
+	client1, _ := NewClient(cc)
+	client2, _ := NewClient(cc)
+	cookie, _ = server.HandleHello(client1.Hello())
+	server.HandleHello(client2.Hello())
+	if _, _, err := client2.Initiate(cookie, nil); err == nil {
+		t.Error("cookie of another client accepted")
+	}
+}
+
+func TestMessageSize(t *testing.T) {
+	sc, cc := testConfigs()
+	server, _ := NewServer(sc)
+	cs, ss := handshake(t, server, cc)
+
+	if _, err := cs.Seal(make([]byte, MaxInitiateMessage+1)); err != ErrMessageTooLarge {
+		t.Errorf("initiate: expected message too large, got %v", err)
+	}
+	if _, err := ss.Seal(make([]byte, MaxServerMessage+1)); err != ErrMessageTooLarge {
+		t.Errorf("server: expected message too large, got %v", err)
+	}
+
+	p, _ := ss.Seal(make([]byte, MaxServerMessage))
+	if _, err := cs.Open(p); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := cs.Seal(make([]byte, MaxClientMessage+1)); err != ErrMessageTooLarge {
+		t.Errorf("client: expected message too large, got %v", err)
+	}
+	p, _ = cs.Seal(make([]byte, MaxClientMessage))
+	if _, err := ss.Open(p); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoopback(t *testing.T) {
+	sc, cc := testConfigs()
+	server, _ := NewServer(sc)
+	a, b := NewLoopback()
+	defer a.Close()
+
+	type result struct {
+		conn *Conn
+		m    []byte
+		err  error
+	}
+
+	accepted := make(chan result, 1)
+	go func() {
+		conn, m, err := Accept(b, server)
+		accepted <- result{conn, m, err}
+	}()
+
+	// Noise on the transport is ignored
+	a.Send([]byte("noise"))
+
+	client, err := Dial(a, cc, []byte("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := <-accepted
+	if r.err != nil || string(r.m) != "hello" {
+		t.Fatal(r.m, r.err)
+	}
+	if !bytes.Equal(r.conn.Session.RemotePublicKey(), cc.PublicKey) {
+		t.Fatal("incorrect client public key")
+	}
+
+	for i, m := range []string{"one", "two", "three"} {
+		if err := client.Send([]byte(m)); err != nil {
+			t.Fatal(err)
+		}
+		if got, err := r.conn.Receive(); err != nil || string(got) != m {
+			t.Fatal(i, got, err)
+		}
+
+		if err := r.conn.Send([]byte(m)); err != nil {
+			t.Fatal(err)
+		}
+		if got, err := client.Receive(); err != nil || string(got) != m {
+			t.Fatal(i, got, err)
+		}
+	}
+
+	b.Close()
+	if _, err := client.Receive(); err == nil {
+		t.Fatal("receive after close")
+	}
+}
+
+func TestEncodeName(t *testing.T) {
+	name, err := encodeName("www.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "\x03www\x07example\x03com\x00"
+	if string(name[:len(want)]) != want || name[len(want)] != 0 {
+		t.Fatalf("incorrect encoding %q", name[:len(want)])
+	}
+
+	for _, invalid := range []string{"a..b", string(make([]byte, 64)), string(bytes.Repeat([]byte("a."), 128))} {
+		if _, err := encodeName(invalid); err == nil {
+			t.Errorf("invalid name %q accepted", invalid)
+		}
+	}
+}
+
+func isVerificationError(err error) bool {
+	_, ok := err.(*support.VerificationError)
+	return ok
+}
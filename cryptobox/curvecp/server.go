@@ -0,0 +1,305 @@
+package curvecp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"github.com/GoKillers/libsodium-go/cryptobox"
+	secretbox "github.com/GoKillers/libsodium-go/cryptosecretbox"
+	"github.com/GoKillers/libsodium-go/randombytes"
+	"github.com/GoKillers/libsodium-go/sodium"
+	"github.com/GoKillers/libsodium-go/support"
+	"sync"
+	"time"
+)
+
+// ServerConfig configures a server.
+type ServerConfig struct {
+	PublicKey []byte    // Long-term public key of the server
+	SecretKey []byte    // Long-term secret key of the server
+	Extension Extension // Extension of the server
+	Name      string    // Domain name that clients must use, any if empty
+}
+
+// Server answers Hello packets and accepts Initiate packets. It is safe for
+// concurrent use.
+//
+// The server remembers the sessions it started for as long as their cookies are
+// valid, so that repeated Initiate packets go to the session they belong to,
+// which rejects replays.
+type Server struct {
+	pk, sk []byte
+	ext    Extension
+	name   [nameBytes]byte
+
+	mu            sync.Mutex
+	minuteKey     []byte
+	prevMinuteKey []byte
+	generation    uint64 // Number of rotations of the minute key
+	rotated       time.Time
+	now           func() time.Time
+
+	// Sessions by short-term public key of the client, started with cookies
+	// of the current and the previous minute key
+	sessions     map[[PublicKeyBytes]byte]*Session
+	prevSessions map[[PublicKeyBytes]byte]*Session
+}
+
+// NewServer returns a server for a configuration.
+func NewServer(config *ServerConfig) (*Server, error) {
+	support.CheckSize(config.PublicKey, PublicKeyBytes, "public key")
+	support.CheckSize(config.SecretKey, SecretKeyBytes, "secret key")
+
+	name, err := encodeName(config.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Server{
+		pk:   append([]byte(nil), config.PublicKey...),
+		sk:   append([]byte(nil), config.SecretKey...),
+		ext:  config.Extension,
+		name: name,
+		now:  time.Now,
+	}
+
+	s.rotate()
+	s.rotate()
+	s.rotated = s.now()
+
+	return s, nil
+}
+
+// rotate replaces the minute key, keeping the current one as the previous key.
+// Sessions started with cookies of the discarded key are forgotten, since their
+// Initiate packets can no longer be verified.
+func (s *Server) rotate() {
+	if s.prevMinuteKey != nil {
+		sodium.MemZero(s.prevMinuteKey)
+	}
+
+	s.prevMinuteKey = s.minuteKey
+	s.minuteKey = randombytes.RandomBytes(secretbox.CryptoSecretBoxKeyBytes())
+	s.generation++
+
+	s.prevSessions = s.sessions
+	s.sessions = make(map[[PublicKeyBytes]byte]*Session)
+}
+
+// RotateMinuteKey replaces the minute key immediately. Cookies issued under the
+// replaced key remain valid until the next rotation.
+func (s *Server) RotateMinuteKey() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.rotate()
+	s.rotated = s.now()
+}
+
+// minuteKeys returns copies of the current and previous minute key, after
+// replacing them if they are due, and the generation of the current key.
+// The copies can be used after a concurrent rotation zeroes the keys, and
+// must be zeroed by the caller.
+func (s *Server) minuteKeys() (cur, prev []byte, generation uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if elapsed := now.Sub(s.rotated); elapsed >= MinuteKeyInterval {
+		s.rotate()
+		if elapsed >= 2*MinuteKeyInterval {
+			s.rotate()
+		}
+		s.rotated = now
+	}
+
+	cur = append([]byte(nil), s.minuteKey...)
+	prev = append([]byte(nil), s.prevMinuteKey...)
+
+	return cur, prev, s.generation
+}
+
+// HandleHello verifies a Hello packet and returns the Cookie packet to send
+// back. The server keeps no state for the client.
+func (s *Server) HandleHello(p []byte) ([]byte, error) {
+	if !checkHeader(p, helloMagic, helloBytes, helloBytes, &s.ext) {
+		return nil, errInvalidPacket
+	}
+
+	clientExt := (*Extension)(p[24:40])
+	clientShort := p[40:72]
+	n := p[136:144]
+
+	k, err := beforeNm(clientShort, s.sk)
+	if err != nil {
+		return nil, err
+	}
+	defer sodium.MemZero(k)
+
+	if _, err := cryptobox.OpenAfterNm(nil, p[144:], nonce(helloNonce, n), k); err != nil {
+		return nil, err
+	}
+
+	sk, pk, _ := cryptobox.CryptoBoxKeyPair()
+	defer sodium.MemZero(sk)
+
+	// The cookie holds the short-term keys of the client and the server
+	keys := append(append(make([]byte, 0, 2*PublicKeyBytes), clientShort...), sk...)
+	defer sodium.MemZero(keys)
+
+	minuteKey, prevMinuteKey, _ := s.minuteKeys()
+	defer sodium.MemZero(minuteKey)
+	sodium.MemZero(prevMinuteKey)
+
+	cn := randombytes.RandomBytes(16)
+	cookie := secretbox.Seal(cn, keys, nonce(minuteNonce, cn), minuteKey)
+
+	out := make([]byte, 0, cookiePacketBytes)
+	out = appendHeader(out, cookieMagic, clientExt, &s.ext)
+	out = append(out, randombytes.RandomBytes(16)...)
+	out = cryptobox.SealAfterNm(out, append(pk, cookie...), nonce(cookieNonce, out[40:56]), k)
+
+	return out, nil
+}
+
+// openCookie decrypts a cookie with the current or previous minute key and
+// returns the short-term keys of the client and the server, together with the
+// generation of the minute key.
+func (s *Server) openCookie(cookie []byte) ([]byte, uint64, error) {
+	cur, prev, generation := s.minuteKeys()
+	defer sodium.MemZero(cur)
+	defer sodium.MemZero(prev)
+
+	n := nonce(minuteNonce, cookie[:16])
+	for i, key := range [][]byte{cur, prev} {
+		if keys, err := secretbox.Open(nil, cookie[16:], n, key); err == nil {
+			return keys, generation - uint64(i), nil
+		}
+	}
+
+	return nil, 0, errors.New("curvecp: expired or invalid cookie")
+}
+
+// session returns the session started for a short-term public key of a client, if any.
+func (s *Server) session(clientShort []byte) *Session {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := *(*[PublicKeyBytes]byte)(clientShort)
+	if session, ok := s.sessions[key]; ok {
+		return session
+	}
+
+	return s.prevSessions[key]
+}
+
+// addSession records a session started with a cookie of a given generation of
+// the minute key. If a session was already started for the same client
+// short-term key, that session is returned instead.
+func (s *Server) addSession(session *Session, generation uint64) *Session {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := *(*[PublicKeyBytes]byte)(session.clientShort)
+	if existing, ok := s.sessions[key]; ok {
+		return existing
+	}
+	if existing, ok := s.prevSessions[key]; ok {
+		return existing
+	}
+
+	switch generation {
+	case s.generation:
+		s.sessions[key] = session
+	case s.generation - 1:
+		s.prevSessions[key] = session
+	}
+
+	return session
+}
+
+// HandleInitiate verifies an Initiate packet and returns the session it starts
+// together with the message it carries. The caller must check the long-term
+// public key of the client, returned by Session.RemotePublicKey.
+//
+// A client repeats Initiate packets until the server answers. Such a packet
+// returns the session already started, and is rejected like Session.Open
+// rejects it if it is a replay.
+func (s *Server) HandleInitiate(p []byte) (*Session, []byte, error) {
+	session, m, _, err := s.handleInitiate(p)
+	return session, m, err
+}
+
+// handleInitiate is HandleInitiate, and also reports whether the session was
+// started by this packet.
+func (s *Server) handleInitiate(p []byte) (session *Session, m []byte, started bool, err error) {
+	if !checkHeader(p, initiateMagic, initiateBytes, initiateBytes+MaxInitiateMessage, &s.ext) {
+		return nil, nil, false, errInvalidPacket
+	}
+
+	clientExt := (*Extension)(p[24:40])
+	clientShort := p[40:72]
+	n := p[168:176]
+
+	if existing := s.session(clientShort); existing != nil {
+		if m, err = existing.Open(p); err != nil {
+			return nil, nil, false, err
+		}
+
+		return existing, m, false, nil
+	}
+
+	keys, generation, err := s.openCookie(p[72:168])
+	if err != nil {
+		return nil, nil, false, err
+	}
+	defer sodium.MemZero(keys)
+
+	if !bytes.Equal(keys[:PublicKeyBytes], clientShort) {
+		return nil, nil, false, errInvalidPacket
+	}
+
+	k, err := beforeNm(clientShort, keys[PublicKeyBytes:])
+	if err != nil {
+		return nil, nil, false, err
+	}
+
+	box, err := cryptobox.OpenAfterNm(nil, p[initiateHeaderBytes:], nonce(initiateNonce, n), k)
+	if err != nil {
+		return nil, nil, false, err
+	}
+
+	client := box[:PublicKeyBytes]
+	vouch, err := cryptobox.Open(nil, box[48:96], nonce(vouchNonce, box[32:48]), client, s.sk)
+	if err != nil {
+		return nil, nil, false, err
+	}
+
+	if !bytes.Equal(vouch, clientShort) {
+		return nil, nil, false, errInvalidPacket
+	}
+
+	if s.name != [nameBytes]byte{} && !bytes.Equal(box[96:vouchBytes], s.name[:]) {
+		return nil, nil, false, errors.New("curvecp: incorrect server name")
+	}
+
+	session = &Session{
+		key:         k,
+		serverExt:   s.ext,
+		clientExt:   *clientExt,
+		clientShort: append([]byte(nil), clientShort...),
+		remote:      append([]byte(nil), client...),
+		recvNonce:   binary.LittleEndian.Uint64(n),
+	}
+
+	// The same packet may have been accepted concurrently
+	if existing := s.addSession(session, generation); existing != session {
+		if m, err = existing.Open(p); err != nil {
+			return nil, nil, false, err
+		}
+
+		return existing, m, false, nil
+	}
+
+	return session, box[vouchBytes:], true, nil
+}
@@ -0,0 +1,149 @@
+package curvecp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"github.com/GoKillers/libsodium-go/cryptobox"
+	"github.com/GoKillers/libsodium-go/support"
+	"math"
+	"sync"
+)
+
+// Session encrypts and decrypts the messages of a connection after a handshake.
+// It is safe for concurrent use.
+type Session struct {
+	client      bool
+	key         []byte // Shared key of the short-term keys
+	serverExt   Extension
+	clientExt   Extension
+	clientShort []byte // Short-term public key of the client
+	remote      []byte // Long-term public key of the peer
+
+	mu        sync.Mutex
+	sendNonce uint64 // Last nonce sent
+	recvNonce uint64 // Last nonce received
+
+	// Until the client receives a message from the server, it sends Initiate
+	// packets with the cookie and the vouch, long-term key and server name
+	cookie []byte
+	vouch  []byte
+}
+
+// RemotePublicKey returns the long-term public key of the peer.
+func (s *Session) RemotePublicKey() []byte {
+	return s.remote
+}
+
+// Seal returns a packet that carries `message` to the peer.
+// ErrMessageTooLarge is returned if the message does not fit in the packet.
+func (s *Session) Seal(message []byte) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.sendNonce == math.MaxUint64 {
+		return nil, &support.NonceExhaustedError{}
+	}
+
+	var out []byte
+	var prefix string
+
+	switch {
+	case !s.client:
+		if len(message) > MaxServerMessage {
+			return nil, ErrMessageTooLarge
+		}
+
+		out = make([]byte, 0, serverMessageBytes+len(message))
+		out = appendHeader(out, serverMessageMagic, &s.clientExt, &s.serverExt)
+		prefix = serverMessageNonce
+	case s.cookie != nil:
+		if len(message) > MaxInitiateMessage {
+			return nil, ErrMessageTooLarge
+		}
+
+		out = make([]byte, 0, initiateBytes+len(message))
+		out = appendHeader(out, initiateMagic, &s.serverExt, &s.clientExt)
+		out = append(out, s.clientShort...)
+		out = append(out, s.cookie...)
+		message = append(append(make([]byte, 0, vouchBytes+len(message)), s.vouch...), message...)
+		prefix = initiateNonce
+	default:
+		if len(message) > MaxClientMessage {
+			return nil, ErrMessageTooLarge
+		}
+
+		out = make([]byte, 0, clientMessageBytes+len(message))
+		out = appendHeader(out, clientMessageMagic, &s.serverExt, &s.clientExt)
+		out = append(out, s.clientShort...)
+		prefix = clientMessageNonce
+	}
+
+	s.sendNonce++
+	out = appendNonce(out, s.sendNonce)
+
+	return cryptobox.SealAfterNm(out, message, nonce(prefix, out[len(out)-nonceBytes:]), s.key), nil
+}
+
+// Open verifies a packet from the peer and returns the message it carries.
+// Packets that are invalid, replayed or older than the last accepted packet
+// are rejected.
+func (s *Session) Open(p []byte) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var off int // Offset of the box, which follows the nonce
+	var prefix string
+	var skip int // Size of the box contents before the message
+
+	switch {
+	case s.client:
+		if !checkHeader(p, serverMessageMagic, serverMessageBytes, serverMessageBytes+MaxServerMessage, &s.clientExt) ||
+			!bytes.Equal(p[24:40], s.serverExt[:]) {
+			return nil, errInvalidPacket
+		}
+
+		off = serverMessageBytes - boxMacBytes
+		prefix = serverMessageNonce
+	case TypeOf(p) == Initiate:
+		// The client repeats Initiate packets until it receives a message
+		if !s.checkClientHeader(p, initiateMagic, initiateBytes, initiateBytes+MaxInitiateMessage) {
+			return nil, errInvalidPacket
+		}
+
+		off = initiateHeaderBytes
+		prefix = initiateNonce
+		skip = vouchBytes
+	default:
+		if !s.checkClientHeader(p, clientMessageMagic, clientMessageBytes, clientMessageBytes+MaxClientMessage) {
+			return nil, errInvalidPacket
+		}
+
+		off = clientMessageBytes - boxMacBytes
+		prefix = clientMessageNonce
+	}
+
+	n := p[off-nonceBytes : off]
+	seq := binary.LittleEndian.Uint64(n)
+	if seq <= s.recvNonce {
+		return nil, errReplay
+	}
+
+	m, err := cryptobox.OpenAfterNm(nil, p[off:], nonce(prefix, n), s.key)
+	if err != nil {
+		return nil, err
+	}
+
+	s.recvNonce = seq
+	if s.client {
+		s.cookie = nil
+		s.vouch = nil
+	}
+
+	return m[skip:], nil
+}
+
+// checkClientHeader checks the header of a packet from the client of a session.
+func (s *Session) checkClientHeader(p []byte, magic string, min, max int) bool {
+	return checkHeader(p, magic, min, max, &s.serverExt) &&
+		bytes.Equal(p[24:40], s.clientExt[:]) && bytes.Equal(p[40:72], s.clientShort)
+}
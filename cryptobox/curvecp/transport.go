@@ -0,0 +1,166 @@
+package curvecp
+
+import (
+	"net"
+	"sync"
+)
+
+// Transport sends and receives packets, like a connected UDP socket.
+type Transport interface {
+	Send(packet []byte) error
+	Receive() ([]byte, error)
+}
+
+// loopbackQueue is the number of packets a Loopback buffers.
+const loopbackQueue = 64
+
+// Loopback is an in-memory Transport connected to another Loopback, for
+// testing. Like datagrams, packets are dropped when the queue of the receiver
+// is full.
+type Loopback struct {
+	in   chan []byte
+	out  chan []byte
+	done chan struct{}
+	once *sync.Once
+}
+
+// NewLoopback returns two connected Loopback transports.
+func NewLoopback() (*Loopback, *Loopback) {
+	a, b := make(chan []byte, loopbackQueue), make(chan []byte, loopbackQueue)
+	done := make(chan struct{})
+	once := new(sync.Once)
+
+	return &Loopback{in: a, out: b, done: done, once: once},
+		&Loopback{in: b, out: a, done: done, once: once}
+}
+
+// Send queues a copy of a packet for the other end.
+func (l *Loopback) Send(packet []byte) error {
+	select {
+	case <-l.done:
+		return net.ErrClosed
+	default:
+	}
+
+	select {
+	case l.out <- append([]byte(nil), packet...):
+	default:
+	}
+
+	return nil
+}
+
+// Receive waits for the next packet from the other end.
+func (l *Loopback) Receive() ([]byte, error) {
+	select {
+	case p := <-l.in:
+		return p, nil
+	case <-l.done:
+		return nil, net.ErrClosed
+	}
+}
+
+// Close closes both ends.
+func (l *Loopback) Close() error {
+	l.once.Do(func() { close(l.done) })
+	return nil
+}
+
+// Conn is a session over a transport.
+type Conn struct {
+	Session   *Session
+	transport Transport
+}
+
+// Dial performs the handshake with a server over a transport and sends the
+// first message in the Initiate packet. Packets that are not a valid Cookie
+// packet are ignored. Dial does not retransmit the Hello packet.
+func Dial(t Transport, config *ClientConfig, message []byte) (*Conn, error) {
+	if len(message) > MaxInitiateMessage {
+		return nil, ErrMessageTooLarge
+	}
+
+	client, err := NewClient(config)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := t.Send(client.Hello()); err != nil {
+		return nil, err
+	}
+
+	for {
+		p, err := t.Receive()
+		if err != nil {
+			return nil, err
+		}
+
+		session, initiate, err := client.Initiate(p, message)
+		if err != nil {
+			continue
+		}
+
+		if err := t.Send(initiate); err != nil {
+			return nil, err
+		}
+
+		return &Conn{Session: session, transport: t}, nil
+	}
+}
+
+// Accept answers Hello packets from a transport until it receives a valid
+// Initiate packet that starts a session, and returns the connection together
+// with the message it carries. Other packets, including replayed Initiate
+// packets and those of sessions already started, are ignored.
+func Accept(t Transport, server *Server) (*Conn, []byte, error) {
+	for {
+		p, err := t.Receive()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		switch TypeOf(p) {
+		case Hello:
+			cookie, err := server.HandleHello(p)
+			if err != nil {
+				continue
+			}
+
+			if err := t.Send(cookie); err != nil {
+				return nil, nil, err
+			}
+		case Initiate:
+			session, m, started, err := server.handleInitiate(p)
+			if err != nil || !started {
+				continue
+			}
+
+			return &Conn{Session: session, transport: t}, m, nil
+		}
+	}
+}
+
+// Send sends a message to the peer.
+func (c *Conn) Send(message []byte) error {
+	p, err := c.Session.Seal(message)
+	if err != nil {
+		return err
+	}
+
+	return c.transport.Send(p)
+}
+
+// Receive returns the next message from the peer. Packets that are invalid or
+// replayed are ignored.
+func (c *Conn) Receive() ([]byte, error) {
+	for {
+		p, err := c.transport.Receive()
+		if err != nil {
+			return nil, err
+		}
+
+		if m, err := c.Session.Open(p); err == nil {
+			return m, nil
+		}
+	}
+}
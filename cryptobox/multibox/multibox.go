@@ -0,0 +1,250 @@
+// Package multibox encrypts a message for several recipients at once.
+//
+// The body of a message is encrypted once with XChaCha20-Poly1305-IETF under a
+// random payload key, and the payload key is sealed to each recipient's public
+// key with an anonymous cryptobox (crypto_box_seal). The size of a message
+// therefore grows by a fixed amount per recipient, independent of the body.
+//
+// A message can optionally be authenticated by a sender key pair. The payload
+// key is then also boxed with crypto_box_easy from the sender to each recipient,
+// together with a hash of the encrypted body, so a recipient can verify who sent
+// the message and that no other recipient substituted the body. The public key
+// of the sender is only visible to recipients.
+//
+// The public keys of the recipients are stored in the clear, unless recipients
+// are hidden, in which case a recipient finds its slot by trying to open each one.
+//
+// A message has the format
+//
+//	magic | flags | number of recipients (2 bytes, big-endian) | nonce | slots | body
+//
+// where the body is authenticated together with everything preceding the slots.
+package multibox
+
+import (
+	"encoding/binary"
+	"errors"
+	"github.com/GoKillers/libsodium-go/crypto/aead/xchacha20poly1305ietf"
+	"github.com/GoKillers/libsodium-go/cryptobox"
+	"github.com/GoKillers/libsodium-go/cryptogenerichash"
+	"github.com/GoKillers/libsodium-go/randombytes"
+	"github.com/GoKillers/libsodium-go/sodium"
+	"github.com/GoKillers/libsodium-go/support"
+)
+
+// magic identifies a message and the version of its format.
+const magic = "LSMB\x01"
+
+// Flags of a message.
+const (
+	flagAuthenticated = 1 << iota
+	flagHidden
+)
+
+const (
+	publicKeyBytes = 32 // Size of a cryptobox public key
+	boxNonceBytes  = 24 // Size of a cryptobox nonce
+	boxMacBytes    = 16 // Size of a cryptobox authentication tag
+	sealBytes      = 48 // Overhead of an anonymous cryptobox
+	bodyHashBytes  = 32 // Size of the hash of the body in an authenticated slot
+
+	prefixBytes = len(magic) + 3 + xchacha20poly1305ietf.NonceBytes
+)
+
+// ErrNotRecipient is returned by Open when the message is not addressed to the key pair.
+var ErrNotRecipient = errors.New("multibox: not a recipient of the message")
+
+var errInvalid = errors.New("multibox: invalid message")
+
+// Options configures Seal. The zero value seals an anonymous message
+// with visible recipients.
+type Options struct {
+	// SenderPublicKey and SenderSecretKey authenticate the message if set.
+	SenderPublicKey []byte
+	SenderSecretKey []byte
+
+	// HideRecipients omits the public keys of the recipients from the message.
+	HideRecipients bool
+}
+
+// slotSize returns the size of a recipient slot for a message with the given flags.
+func slotSize(flags byte) int {
+	// The sealed payload key, or the sealed sender public key, box nonce and
+	// box of the payload key and body hash
+	n := xchacha20poly1305ietf.KeyBytes + sealBytes
+	if flags&flagAuthenticated != 0 {
+		n += publicKeyBytes + boxNonceBytes + bodyHashBytes + boxMacBytes
+	}
+
+	if flags&flagHidden == 0 {
+		n += publicKeyBytes
+	}
+
+	return n
+}
+
+// Seal encrypts a message `m` for the recipients' public keys. If `opts` is nil,
+// the message is anonymous and the recipients are visible.
+// An error is returned if there are no recipients or a public key is invalid.
+func Seal(m []byte, recipients [][]byte, opts *Options) ([]byte, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+
+	if len(recipients) == 0 || len(recipients) > 0xffff {
+		return nil, errors.New("multibox: invalid number of recipients")
+	}
+
+	var flags byte
+	if opts.SenderSecretKey != nil {
+		support.CheckSize(opts.SenderPublicKey, cryptobox.CryptoBoxPublicKeyBytes(), "sender public key")
+		support.CheckSize(opts.SenderSecretKey, cryptobox.CryptoBoxSecretKeyBytes(), "sender secret key")
+		flags |= flagAuthenticated
+	}
+	if opts.HideRecipients {
+		flags |= flagHidden
+	}
+
+	k := xchacha20poly1305ietf.GenerateKey()
+	defer sodium.MemZero(k[:])
+
+	size := prefixBytes + len(recipients)*slotSize(flags)
+	out := make([]byte, 0, size+len(m)+xchacha20poly1305ietf.ABytes)
+	out = append(out, magic...)
+	out = append(out, flags)
+	out = binary.BigEndian.AppendUint16(out, uint16(len(recipients)))
+	out = append(out, randombytes.RandomBytes(xchacha20poly1305ietf.NonceBytes)...)
+
+	// The body is encrypted first, so that authenticated slots can include its hash
+	nonce := (*[xchacha20poly1305ietf.NonceBytes]byte)(out[len(magic)+3:])
+	body := xchacha20poly1305ietf.Seal(nil, m, out, nonce, k)
+
+	var hash []byte
+	if flags&flagAuthenticated != 0 {
+		hash, _ = generichash.CryptoGenericHash(bodyHashBytes, body, nil)
+	}
+
+	for _, pk := range recipients {
+		support.CheckSize(pk, cryptobox.CryptoBoxPublicKeyBytes(), "recipient public key")
+
+		if flags&flagHidden == 0 {
+			out = append(out, pk...)
+		}
+
+		var err error
+		if flags&flagAuthenticated != 0 {
+			out, err = sealAuthenticated(out, k, hash, pk, opts)
+		} else {
+			out, err = cryptobox.SealAnonymous(out, k[:], pk)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return append(out, body...), nil
+}
+
+// sealAuthenticated appends an authenticated slot for a recipient `pk` to `dst`: the sealed
+// sender public key, box nonce and box of the payload key and body hash.
+func sealAuthenticated(dst []byte, k *[xchacha20poly1305ietf.KeyBytes]byte, hash, pk []byte, opts *Options) ([]byte, error) {
+	p := make([]byte, 0, publicKeyBytes+boxNonceBytes+len(k)+len(hash)+boxMacBytes)
+	p = append(p, opts.SenderPublicKey...)
+	p = append(p, randombytes.RandomBytes(boxNonceBytes)...)
+
+	m := append(append(make([]byte, 0, len(k)+len(hash)), k[:]...), hash...)
+	defer sodium.MemZero(m)
+
+	p, err := cryptobox.Seal(p, m, p[publicKeyBytes:], pk, opts.SenderSecretKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return cryptobox.SealAnonymous(dst, p, pk)
+}
+
+// Open decrypts a message sealed by Seal using the recipient's key pair.
+// For an authenticated message it also returns the verified public key of the
+// sender, which the caller must check against the senders it trusts; for an
+// anonymous message the sender is nil.
+// ErrNotRecipient is returned if the message is not addressed to the key pair.
+func Open(c, pk, sk []byte) (m, sender []byte, err error) {
+	support.CheckSize(pk, cryptobox.CryptoBoxPublicKeyBytes(), "public key")
+	support.CheckSize(sk, cryptobox.CryptoBoxSecretKeyBytes(), "secret key")
+
+	if len(c) < prefixBytes || string(c[:len(magic)]) != magic {
+		return nil, nil, errInvalid
+	}
+
+	flags := c[len(magic)]
+	if flags&^(flagAuthenticated|flagHidden) != 0 {
+		return nil, nil, errInvalid
+	}
+
+	count := int(binary.BigEndian.Uint16(c[len(magic)+1:]))
+	size := slotSize(flags)
+	if count == 0 || len(c) < prefixBytes+count*size+xchacha20poly1305ietf.ABytes {
+		return nil, nil, errInvalid
+	}
+
+	slots := c[prefixBytes : prefixBytes+count*size]
+	body := c[prefixBytes+count*size:]
+
+	payload := findSlot(slots, size, flags, pk, sk)
+	if payload == nil {
+		return nil, nil, ErrNotRecipient
+	}
+	defer sodium.MemZero(payload)
+
+	if flags&flagAuthenticated != 0 {
+		sender = payload[:publicKeyBytes]
+		nonce := payload[publicKeyBytes : publicKeyBytes+boxNonceBytes]
+
+		inner, err := cryptobox.Open(nil, payload[publicKeyBytes+boxNonceBytes:], nonce, sender, sk)
+		if err != nil {
+			return nil, nil, err
+		}
+		defer sodium.MemZero(inner)
+
+		// The hash binds the body to the sender, so that another recipient,
+		// who also knows the payload key, can not replace it
+		hash, _ := generichash.CryptoGenericHash(bodyHashBytes, body, nil)
+		if sodium.MemCmp(inner[xchacha20poly1305ietf.KeyBytes:], hash, bodyHashBytes) != 0 {
+			return nil, nil, &support.VerificationError{}
+		}
+
+		payload = inner
+		sender = append([]byte(nil), sender...)
+	}
+
+	k := (*[xchacha20poly1305ietf.KeyBytes]byte)(payload[:xchacha20poly1305ietf.KeyBytes])
+
+	nonce := (*[xchacha20poly1305ietf.NonceBytes]byte)(c[len(magic)+3 : prefixBytes])
+
+	m, err = xchacha20poly1305ietf.Open(nil, body, c[:prefixBytes], nonce, k)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return m, sender, nil
+}
+
+// findSlot returns the opened slot addressed to the key pair, or nil if there is none.
+func findSlot(slots []byte, size int, flags byte, pk, sk []byte) []byte {
+	for i := 0; i < len(slots); i += size {
+		s := slots[i : i+size]
+
+		if flags&flagHidden == 0 {
+			if sodium.MemCmp(s[:publicKeyBytes], pk, publicKeyBytes) != 0 {
+				continue
+			}
+			s = s[publicKeyBytes:]
+		}
+
+		if p, err := cryptobox.OpenAnonymous(nil, s, pk, sk); err == nil {
+			return p
+		}
+	}
+
+	return nil
+}
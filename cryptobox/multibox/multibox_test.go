@@ -0,0 +1,106 @@
+package multibox
+
+import (
+	"bytes"
+	"github.com/GoKillers/libsodium-go/crypto/aead/xchacha20poly1305ietf"
+	"github.com/GoKillers/libsodium-go/cryptobox"
+	"testing"
+)
+
+type keyPair struct{ pk, sk []byte }
+
+func newKeyPair() keyPair {
+	sk, pk, _ := cryptobox.CryptoBoxKeyPair()
+	return keyPair{pk, sk}
+}
+
+func TestSealOpen(t *testing.T) {
+	sender := newKeyPair()
+	recipients := []keyPair{newKeyPair(), newKeyPair(), newKeyPair()}
+	stranger := newKeyPair()
+
+	pks := make([][]byte, len(recipients))
+	for i, r := range recipients {
+		pks[i] = r.pk
+	}
+
+	m := []byte("test message")
+
+	for _, opts := range []*Options{
+		nil,
+		{HideRecipients: true},
+		{SenderPublicKey: sender.pk, SenderSecretKey: sender.sk},
+		{SenderPublicKey: sender.pk, SenderSecretKey: sender.sk, HideRecipients: true},
+	} {
+		c, err := Seal(m, pks, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		hidden := opts != nil && opts.HideRecipients
+		for _, pk := range pks {
+			if bytes.Contains(c, pk) != !hidden {
+				t.Fatalf("Recipient visibility wrong, hidden: %v", hidden)
+			}
+		}
+
+		for _, r := range recipients {
+			p, s, err := Open(c, r.pk, r.sk)
+			if err != nil || !bytes.Equal(p, m) {
+				t.Fatalf("Open failed: %v", err)
+			}
+
+			if opts != nil && opts.SenderSecretKey != nil {
+				if !bytes.Equal(s, sender.pk) {
+					t.Fatal("Wrong sender")
+				}
+			} else if s != nil {
+				t.Fatal("Anonymous message has a sender")
+			}
+		}
+
+		if _, _, err := Open(c, stranger.pk, stranger.sk); err != ErrNotRecipient {
+			t.Fatalf("Expected ErrNotRecipient, got %v", err)
+		}
+
+		// Flipping any bit is detected by at least the recipient of the modified slot
+		for i := range c {
+			c[i] ^= 1
+			detected := false
+			for _, r := range recipients {
+				if _, _, err := Open(c, r.pk, r.sk); err != nil {
+					detected = true
+				}
+			}
+			if !detected {
+				t.Fatalf("Modified byte %d accepted", i)
+			}
+			c[i] ^= 1
+		}
+	}
+}
+
+func TestBodySubstitution(t *testing.T) {
+	sender := newKeyPair()
+	alice, bob := newKeyPair(), newKeyPair()
+	opts := &Options{SenderPublicKey: sender.pk, SenderSecretKey: sender.sk}
+
+	c, _ := Seal([]byte("original"), [][]byte{alice.pk, bob.pk}, opts)
+
+	// Alice recovers the payload key and encrypts another body with it
+	size := slotSize(flagAuthenticated)
+	payload := findSlot(c[prefixBytes:prefixBytes+2*size], size, flagAuthenticated, alice.pk, alice.sk)
+	inner, err := cryptobox.Open(nil, payload[publicKeyBytes+boxNonceBytes:],
+		payload[publicKeyBytes:publicKeyBytes+boxNonceBytes], sender.pk, alice.sk)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	k := (*[xchacha20poly1305ietf.KeyBytes]byte)(inner[:xchacha20poly1305ietf.KeyBytes])
+	nonce := (*[xchacha20poly1305ietf.NonceBytes]byte)(c[len(magic)+3 : prefixBytes])
+	forged := xchacha20poly1305ietf.Seal(c[:prefixBytes+2*size:prefixBytes+2*size], []byte("forged"), c[:prefixBytes], nonce, k)
+
+	if _, _, err := Open(forged, bob.pk, bob.sk); err == nil {
+		t.Fatal("Body substituted by another recipient accepted")
+	}
+}
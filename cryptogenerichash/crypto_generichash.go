@@ -5,10 +5,16 @@ package generichash
 // #include <sodium.h>
 import "C"
 import (
+	"github.com/GoKillers/libsodium-go/sodium"
 	"github.com/GoKillers/libsodium-go/support"
 	"unsafe"
 )
 
+// Sodium should always be initialised
+func init() {
+	sodium.Initialize()
+}
+
 func CryptoGenericHashBytesMin() int {
 	return int(C.crypto_generichash_bytes_min())
 }
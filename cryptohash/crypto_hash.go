@@ -4,6 +4,12 @@ package cryptohash
 // #include <stdlib.h>
 // #include <sodium.h>
 import "C"
+import "github.com/GoKillers/libsodium-go/sodium"
+
+// Sodium should always be initialised
+func init() {
+	sodium.Initialize()
+}
 
 func CryptoHashBytes() int {
 	return int(C.crypto_hash_bytes())
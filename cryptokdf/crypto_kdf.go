@@ -4,7 +4,14 @@ package cryptokdf
 // #include <stdlib.h>
 // #include <sodium.h>
 import "C"
+import "github.com/GoKillers/libsodium-go/sodium"
 import "github.com/GoKillers/libsodium-go/support"
+import "unsafe"
+
+// Sodium should always be initialised
+func init() {
+	sodium.Initialize()
+}
 
 func CryptoKdfKeybytes() int {
 	return int(C.crypto_kdf_keybytes())
@@ -33,12 +40,14 @@ func CryptoKdfDeriveFromKey(l int, i uint64, c string, k []byte) ([]byte, int) {
 	support.CheckSize([]byte(c), CryptoKdfContextbytes(), "contextbytes")
 	support.CheckIntInRange(l, CryptoKdfBytesMin(), CryptoKdfBytesMax(), "subkey_len")
 	out := make([]byte, l)
+	ctx := C.CString(c)
+	defer C.free(unsafe.Pointer(ctx))
 
 	exit := int(C.crypto_kdf_derive_from_key(
 		(*C.uchar)(&out[0]),
 		(C.size_t)(l),
 		(C.uint64_t)(i),
-		C.CString(c),
+		ctx,
 		(*C.uchar)(&k[0])))
 
 	return out, exit
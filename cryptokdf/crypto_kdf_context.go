@@ -0,0 +1,87 @@
+package cryptokdf
+
+// #cgo pkg-config: libsodium
+// #include <stdlib.h>
+// #include <sodium.h>
+import "C"
+import (
+	"github.com/GoKillers/libsodium-go/support"
+	"strconv"
+	"unsafe"
+)
+
+// Sizes of keys, contexts and subkeys.
+const (
+	KeyBytes     int = C.crypto_kdf_KEYBYTES     // Size of a master key in bytes
+	ContextBytes int = C.crypto_kdf_CONTEXTBYTES // Size of a context in bytes
+	BytesMin     int = C.crypto_kdf_BYTES_MIN    // Minimum size of a subkey in bytes
+	BytesMax     int = C.crypto_kdf_BYTES_MAX    // Maximum size of a subkey in bytes
+)
+
+// Context describes what subkeys are used for, so that different parts
+// of an application derive different subkeys from the same master key.
+// It does not have to be secret. Contexts are best declared once as
+// package-level variables, using MustContext or an array literal:
+//
+//	var sessionContext = cryptokdf.MustContext("sessions")
+type Context [ContextBytes]byte
+
+// ContextSizeError is an error that occurs when a context has an incorrect length.
+type ContextSizeError int
+
+func (k ContextSizeError) Error() string {
+	return "invalid context size " + strconv.Itoa(int(k))
+}
+
+// NewContext returns the Context for a string of exactly ContextBytes bytes.
+func NewContext(s string) (Context, error) {
+	var c Context
+
+	if len(s) != ContextBytes {
+		return c, ContextSizeError(len(s))
+	}
+
+	copy(c[:], s)
+
+	return c, nil
+}
+
+// MustContext is like NewContext, but panics if the string has the wrong length.
+// It is intended for the initialization of package-level variables.
+func MustContext(s string) Context {
+	c, err := NewContext(s)
+	if err != nil {
+		panic("cryptokdf: " + err.Error())
+	}
+	return c
+}
+
+// String returns the context as a string.
+func (c Context) String() string {
+	return string(c[:])
+}
+
+// GenerateKey generates a random master key.
+func GenerateKey() *[KeyBytes]byte {
+	k := new([KeyBytes]byte)
+	C.crypto_kdf_keygen((*C.uchar)(&k[0]))
+	return k
+}
+
+// DeriveFromKey derives subkey number `id` of `length` bytes from a master key `k`
+// for a context `ctx`. The length must be between BytesMin and BytesMax.
+func DeriveFromKey(length int, id uint64, ctx Context, k *[KeyBytes]byte) []byte {
+	support.NilPanic(k == nil, "master key")
+	support.CheckIntInRange(length, BytesMin, BytesMax, "subkey")
+
+	out := make([]byte, length)
+
+	C.crypto_kdf_derive_from_key(
+		(*C.uchar)(&out[0]),
+		(C.size_t)(length),
+		(C.uint64_t)(id),
+		(*C.char)(unsafe.Pointer(&ctx[0])),
+		(*C.uchar)(&k[0]))
+
+	return out
+}
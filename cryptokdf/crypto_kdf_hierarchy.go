@@ -0,0 +1,197 @@
+package cryptokdf
+
+// #cgo pkg-config: libsodium
+// #include <stdlib.h>
+// #include <sodium.h>
+import "C"
+import (
+	"encoding/binary"
+	"errors"
+	"github.com/GoKillers/libsodium-go/sodium"
+	"github.com/GoKillers/libsodium-go/support"
+	"sync"
+)
+
+// KeyHierarchy derives a tree of named keys from a master key, such as
+// root -> tenant -> purpose -> version. A node is identified by the path of
+// names leading to it, and its key is derived from the key of its parent,
+// the name of the node and the context of the hierarchy.
+//
+// Derived keys are cached in secure memory, which is read-only while cached,
+// so the cost of deriving a key is paid once per node. Every cached key uses
+// some locked memory, so a hierarchy should be closed when it is no longer needed.
+//
+// The key of a node can be exported with Key: a KeyHierarchy created with the
+// same context and that key derives the same keys below that node, without
+// revealing the keys of its parent or siblings.
+//
+// A KeyHierarchy is safe for concurrent use.
+type KeyHierarchy struct {
+	ctx   Context
+	mu    sync.Mutex
+	root  *sodium.SecureBuffer
+	cache map[string]*sodium.SecureBuffer
+}
+
+// NewKeyHierarchy returns a KeyHierarchy rooted at a master key `k`,
+// using the context `ctx` for all derivations. The master key is copied
+// to secure memory.
+func NewKeyHierarchy(ctx Context, k *[KeyBytes]byte) (*KeyHierarchy, error) {
+	support.NilPanic(k == nil, "master key")
+
+	root, err := newSecureKey(k[:])
+	if err != nil {
+		return nil, err
+	}
+
+	return &KeyHierarchy{
+		ctx:   ctx,
+		root:  root,
+		cache: make(map[string]*sodium.SecureBuffer),
+	}, nil
+}
+
+// newSecureKey returns a read-only SecureBuffer containing a copy of a key.
+func newSecureKey(k []byte) (*sodium.SecureBuffer, error) {
+	b, err := sodium.NewSecureBuffer(KeyBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	copy(b.Bytes(), k)
+
+	if err := b.ReadOnly(); err != nil {
+		b.Free()
+		return nil, err
+	}
+
+	return b, nil
+}
+
+// Key returns a copy of the key of the node at `path`, which must contain
+// at least one non-empty name.
+func (h *KeyHierarchy) Key(path ...string) (*[KeyBytes]byte, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	b, err := h.node(path)
+	if err != nil {
+		return nil, err
+	}
+
+	k := new([KeyBytes]byte)
+	copy(k[:], b.Bytes())
+
+	return k, nil
+}
+
+// Subtree returns a KeyHierarchy rooted at the node at `path`. It derives the
+// same keys as the receiver below that node, and remains usable after the
+// receiver is closed.
+func (h *KeyHierarchy) Subtree(path ...string) (*KeyHierarchy, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	b, err := h.node(path)
+	if err != nil {
+		return nil, err
+	}
+
+	root, err := newSecureKey(b.Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	return &KeyHierarchy{
+		ctx:   h.ctx,
+		root:  root,
+		cache: make(map[string]*sodium.SecureBuffer),
+	}, nil
+}
+
+// Close frees the master key and all cached keys.
+// The hierarchy can not be used afterwards.
+func (h *KeyHierarchy) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.root == nil {
+		return
+	}
+
+	for _, b := range h.cache {
+		b.Free()
+	}
+
+	h.root.Free()
+	h.root, h.cache = nil, nil
+}
+
+// node returns the cached key of the node at `path`, deriving the keys
+// of the node and its uncached ancestors. The caller must hold the lock.
+func (h *KeyHierarchy) node(path []string) (*sodium.SecureBuffer, error) {
+	if h.root == nil {
+		return nil, errors.New("cryptokdf: key hierarchy is closed")
+	}
+
+	if len(path) == 0 {
+		return nil, errors.New("cryptokdf: empty key path")
+	}
+
+	parent := h.root
+	id := make([]byte, 0, 64)
+
+	for _, name := range path {
+		if name == "" {
+			return nil, errors.New("cryptokdf: empty name in key path")
+		}
+
+		// Names are length-prefixed, so that every path has a unique identifier
+		id = binary.LittleEndian.AppendUint32(id, uint32(len(name)))
+		id = append(id, name...)
+
+		if b, ok := h.cache[string(id)]; ok {
+			parent = b
+			continue
+		}
+
+		b, err := sodium.NewSecureBuffer(KeyBytes)
+		if err != nil {
+			return nil, err
+		}
+
+		h.deriveChild(b.Bytes(), parent.Bytes(), name)
+
+		if err := b.ReadOnly(); err != nil {
+			b.Free()
+			return nil, err
+		}
+
+		h.cache[string(id)] = b
+		parent = b
+	}
+
+	return parent, nil
+}
+
+// deriveChild derives the key of the child `name` of a node with key `parent`.
+// The key is BLAKE2b(key = parent, message = name, personalization = context),
+// the construction of crypto_kdf_derive_from_key with the name as message
+// instead of a numeric identifier as salt.
+func (h *KeyHierarchy) deriveChild(out, parent []byte, name string) {
+	var salt [C.crypto_generichash_blake2b_SALTBYTES]byte
+	var personal [C.crypto_generichash_blake2b_PERSONALBYTES]byte
+	copy(personal[:], h.ctx[:])
+
+	n := []byte(name)
+
+	C.crypto_generichash_blake2b_salt_personal(
+		(*C.uchar)(&out[0]),
+		(C.size_t)(len(out)),
+		(*C.uchar)(&n[0]),
+		(C.ulonglong)(len(n)),
+		(*C.uchar)(&parent[0]),
+		(C.size_t)(len(parent)),
+		(*C.uchar)(&salt[0]),
+		(*C.uchar)(&personal[0]))
+}
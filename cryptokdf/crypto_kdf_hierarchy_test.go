@@ -0,0 +1,93 @@
+package cryptokdf
+
+import (
+	"bytes"
+	"testing"
+)
+
+var testContext = MustContext("testtest")
+
+func TestContext(t *testing.T) {
+	if _, err := NewContext("short"); err == nil {
+		t.Fatal("NewContext accepted a short context")
+	}
+
+	if testContext.String() != "testtest" {
+		t.Fatalf("Wrong context %q", testContext)
+	}
+
+	k := GenerateKey()
+	legacy, _ := CryptoKdfDeriveFromKey(32, 7, "testtest", k[:])
+	if !bytes.Equal(DeriveFromKey(32, 7, testContext, k), legacy) {
+		t.Fatal("DeriveFromKey differs from CryptoKdfDeriveFromKey")
+	}
+}
+
+func TestKeyHierarchy(t *testing.T) {
+	h, err := NewKeyHierarchy(testContext, GenerateKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+
+	k1, err := h.Key("tenant", "purpose", "v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Cached and uncached derivations agree
+	k2, _ := h.Key("tenant", "purpose", "v1")
+	if *k1 != *k2 {
+		t.Fatal("Derivation is not deterministic")
+	}
+
+	// Different paths, including ambiguous concatenations, give different keys
+	for _, path := range [][]string{
+		{"tenant", "purpose", "v2"},
+		{"tenant", "purposev1"},
+		{"tenant", "purpose"},
+		{"other", "purpose", "v1"},
+	} {
+		if k, _ := h.Key(path...); *k == *k1 {
+			t.Fatalf("Same key for %v", path)
+		}
+	}
+
+	// An exported subtree derives the same keys
+	exported, _ := h.Key("tenant")
+	sub, err := NewKeyHierarchy(testContext, exported)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Close()
+
+	if k, _ := sub.Key("purpose", "v1"); *k != *k1 {
+		t.Fatal("Exported subtree derives different keys")
+	}
+
+	subtree, _ := h.Subtree("tenant", "purpose")
+	defer subtree.Close()
+	if k, _ := subtree.Key("v1"); *k != *k1 {
+		t.Fatal("Subtree derives different keys")
+	}
+
+	// Another context gives different keys
+	other, _ := NewKeyHierarchy(MustContext("othertst"), exported)
+	defer other.Close()
+	if k, _ := other.Key("purpose", "v1"); *k == *k1 {
+		t.Fatal("Context does not affect derivation")
+	}
+
+	if _, err := h.Key(); err == nil {
+		t.Fatal("Empty path accepted")
+	}
+
+	if _, err := h.Key("tenant", ""); err == nil {
+		t.Fatal("Empty name accepted")
+	}
+
+	h.Close()
+	if _, err := h.Key("tenant"); err == nil {
+		t.Fatal("Closed hierarchy still usable")
+	}
+}
@@ -0,0 +1,56 @@
+package hkdfsha256
+
+import (
+	"bytes"
+	"encoding/hex"
+	"io"
+	"testing"
+)
+
+// Test case 1 of RFC 5869
+var (
+	testIKM     = bytes.Repeat([]byte{0x0b}, 22)
+	testSalt, _ = hex.DecodeString("000102030405060708090a0b0c")
+	testInfo, _ = hex.DecodeString("f0f1f2f3f4f5f6f7f8f9")
+	testPRK, _  = hex.DecodeString("077709362c2e32df0ddc3f0dc47bba6390b6c73bb50f9c3122ec844ad7c2b3e5")
+	testOKM, _  = hex.DecodeString("3cb25f25faacd57a90434f64d0362f2a2d2d0a90cf1a5a4c5db02d56ecc4c5bf34007208d5b887185865")
+)
+
+func TestExtractExpand(t *testing.T) {
+	prk := Extract(testSalt, testIKM)
+	if !bytes.Equal(prk[:], testPRK) {
+		t.Fatalf("Wrong PRK: %x", prk[:])
+	}
+
+	if okm := Expand(len(testOKM), testInfo, prk); !bytes.Equal(okm, testOKM) {
+		t.Fatalf("Wrong OKM: %x", okm)
+	}
+
+	s := Init(testSalt)
+	s.Update(testIKM[:10])
+	io.WriteString(s, string(testIKM[10:]))
+	if prk := s.Final(); !bytes.Equal(prk[:], testPRK) {
+		t.Fatalf("Wrong streaming PRK: %x", prk[:])
+	}
+}
+
+func TestExpander(t *testing.T) {
+	prk := GenerateKey()
+	expected := Expand(BytesMax, testInfo, prk)
+
+	// Read in pieces that do not align with the blocks
+	r := NewExpander(testInfo, prk)
+	out := make([]byte, 0, BytesMax)
+	buf := make([]byte, 50)
+	for {
+		n, err := r.Read(buf)
+		out = append(out, buf[:n]...)
+		if err == io.EOF {
+			break
+		}
+	}
+
+	if !bytes.Equal(out, expected) {
+		t.Fatal("Expander output differs from Expand")
+	}
+}
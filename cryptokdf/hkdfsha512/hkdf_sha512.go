@@ -0,0 +1,164 @@
+// Package hkdfsha512 contains the libsodium bindings for HKDF (RFC 5869) with HMAC-SHA-512.
+// It has the same API as package hkdfsha256, with larger pseudorandom keys.
+package hkdfsha512
+
+// #cgo pkg-config: libsodium
+// #include <stdlib.h>
+// #include <sodium.h>
+import "C"
+import (
+	"github.com/GoKillers/libsodium-go/sodium"
+	"github.com/GoKillers/libsodium-go/support"
+	"io"
+	"unsafe"
+)
+
+// Sodium should always be initialised
+func init() {
+	sodium.Initialize()
+}
+
+// Sizes of keys and derived keys.
+const (
+	KeyBytes int = C.crypto_kdf_hkdf_sha512_KEYBYTES  // Size of a pseudorandom key in bytes
+	BytesMin int = C.crypto_kdf_hkdf_sha512_BYTES_MIN // Minimum size of a derived key in bytes
+	BytesMax int = C.crypto_kdf_hkdf_sha512_BYTES_MAX // Maximum size of a derived key in bytes
+)
+
+// GenerateKey generates a random pseudorandom key, which can be used with Expand
+// when no input keying material has to be extracted.
+func GenerateKey() *[KeyBytes]byte {
+	k := new([KeyBytes]byte)
+	C.crypto_kdf_hkdf_sha512_keygen((*C.uchar)(&k[0]))
+	return k
+}
+
+// Extract computes a pseudorandom key from input keying material `ikm` and an optional `salt`.
+func Extract(salt, ikm []byte) *[KeyBytes]byte {
+	prk := new([KeyBytes]byte)
+
+	C.crypto_kdf_hkdf_sha512_extract(
+		(*C.uchar)(&prk[0]),
+		(*C.uchar)(support.BytePointer(salt)),
+		(C.size_t)(len(salt)),
+		(*C.uchar)(support.BytePointer(ikm)),
+		(C.size_t)(len(ikm)))
+
+	return prk
+}
+
+// Expand derives a key of `length` bytes from a pseudorandom key `prk`,
+// bound to the context `info`. The length must be at most BytesMax.
+func Expand(length int, info []byte, prk *[KeyBytes]byte) []byte {
+	support.NilPanic(prk == nil, "pseudorandom key")
+	support.CheckIntInRange(length, BytesMin, BytesMax, "derived key")
+
+	out := make([]byte, length)
+
+	C.crypto_kdf_hkdf_sha512_expand(
+		(*C.uchar)(support.BytePointer(out)),
+		(C.size_t)(length),
+		(*C.char)(unsafe.Pointer(support.BytePointer(info))),
+		(C.size_t)(len(info)),
+		(*C.uchar)(&prk[0]))
+
+	return out
+}
+
+// State is the state of a streaming extraction, for input keying material
+// that is not available at once. It implements io.Writer.
+type State struct {
+	state C.crypto_kdf_hkdf_sha512_state
+}
+
+// Init starts a streaming extraction with an optional `salt`.
+func Init(salt []byte) *State {
+	s := new(State)
+
+	C.crypto_kdf_hkdf_sha512_extract_init(
+		&s.state,
+		(*C.uchar)(support.BytePointer(salt)),
+		(C.size_t)(len(salt)))
+
+	return s
+}
+
+// Update adds input keying material `ikm` to the extraction.
+func (s *State) Update(ikm []byte) {
+	C.crypto_kdf_hkdf_sha512_extract_update(
+		&s.state,
+		(*C.uchar)(support.BytePointer(ikm)),
+		(C.size_t)(len(ikm)))
+}
+
+// Write adds input keying material to the extraction. It never returns an error.
+func (s *State) Write(p []byte) (int, error) {
+	s.Update(p)
+	return len(p), nil
+}
+
+// Final returns the pseudorandom key. The state must not be used afterwards.
+func (s *State) Final() *[KeyBytes]byte {
+	prk := new([KeyBytes]byte)
+	C.crypto_kdf_hkdf_sha512_extract_final(&s.state, (*C.uchar)(&prk[0]))
+	return prk
+}
+
+// expander is an io.Reader returning the output of Expand.
+type expander struct {
+	prk     [KeyBytes]byte
+	info    []byte
+	counter byte
+	block   [C.crypto_auth_hmacsha512_BYTES]byte // Last output block T(counter)
+	pos     int                                  // Number of bytes of block already read
+}
+
+// NewExpander returns a reader that returns the output of Expand for a pseudorandom
+// key `prk` and a context `info`, so that several keys can be read in sequence.
+// The reader returns io.EOF after BytesMax bytes.
+func NewExpander(info []byte, prk *[KeyBytes]byte) io.Reader {
+	support.NilPanic(prk == nil, "pseudorandom key")
+
+	e := &expander{prk: *prk, info: make([]byte, len(info))}
+	copy(e.info, info)
+	e.pos = len(e.block)
+
+	return e
+}
+
+// Read implements io.Reader.
+func (e *expander) Read(p []byte) (int, error) {
+	n := 0
+
+	for n < len(p) {
+		if e.pos == len(e.block) {
+			if e.counter == 0xff {
+				return n, io.EOF
+			}
+			e.next()
+		}
+
+		m := copy(p[n:], e.block[e.pos:])
+		e.pos += m
+		n += m
+	}
+
+	return n, nil
+}
+
+// next computes the next output block T(n) = HMAC(prk, T(n-1) | info | n).
+func (e *expander) next() {
+	var st C.crypto_auth_hmacsha512_state
+
+	C.crypto_auth_hmacsha512_init(&st, (*C.uchar)(&e.prk[0]), C.size_t(len(e.prk)))
+	if e.counter > 0 {
+		C.crypto_auth_hmacsha512_update(&st, (*C.uchar)(&e.block[0]), C.ulonglong(len(e.block)))
+	}
+	C.crypto_auth_hmacsha512_update(&st, (*C.uchar)(support.BytePointer(e.info)), C.ulonglong(len(e.info)))
+
+	e.counter++
+	C.crypto_auth_hmacsha512_update(&st, (*C.uchar)(&e.counter), 1)
+	C.crypto_auth_hmacsha512_final(&st, (*C.uchar)(&e.block[0]))
+
+	e.pos = 0
+}
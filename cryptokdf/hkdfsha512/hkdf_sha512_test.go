@@ -0,0 +1,68 @@
+package hkdfsha512
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha512"
+	"io"
+	"testing"
+)
+
+// referenceHKDF implements HKDF-SHA-512 with the standard library.
+func referenceHKDF(salt, ikm, info []byte, length int) (prk, okm []byte) {
+	mac := hmac.New(sha512.New, salt)
+	mac.Write(ikm)
+	prk = mac.Sum(nil)
+
+	var t []byte
+	for i := byte(1); len(okm) < length; i++ {
+		mac = hmac.New(sha512.New, prk)
+		mac.Write(t)
+		mac.Write(info)
+		mac.Write([]byte{i})
+		t = mac.Sum(nil)
+		okm = append(okm, t...)
+	}
+
+	return prk, okm[:length]
+}
+
+func TestExtractExpand(t *testing.T) {
+	salt := []byte("salt")
+	ikm := []byte("input keying material")
+	info := []byte("context")
+	expectedPRK, expectedOKM := referenceHKDF(salt, ikm, info, 150)
+
+	prk := Extract(salt, ikm)
+	if !bytes.Equal(prk[:], expectedPRK) {
+		t.Fatalf("Wrong PRK: %x", prk[:])
+	}
+
+	if okm := Expand(len(expectedOKM), info, prk); !bytes.Equal(okm, expectedOKM) {
+		t.Fatalf("Wrong OKM: %x", okm)
+	}
+
+	s := Init(salt)
+	s.Update(ikm[:5])
+	io.WriteString(s, string(ikm[5:]))
+	if prk := s.Final(); !bytes.Equal(prk[:], expectedPRK) {
+		t.Fatalf("Wrong streaming PRK: %x", prk[:])
+	}
+
+	// Without salt
+	expectedPRK, _ = referenceHKDF(nil, ikm, info, 1)
+	if prk := Extract(nil, ikm); !bytes.Equal(prk[:], expectedPRK) {
+		t.Fatalf("Wrong PRK without salt: %x", prk[:])
+	}
+}
+
+func TestExpander(t *testing.T) {
+	prk := GenerateKey()
+	info := []byte("context")
+	expected := Expand(BytesMax, info, prk)
+
+	out, err := io.ReadAll(NewExpander(info, prk))
+	if err != nil || !bytes.Equal(out, expected) {
+		t.Fatalf("Expander output differs from Expand: %v", err)
+	}
+}
@@ -0,0 +1,58 @@
+package secretbox
+
+// #cgo pkg-config: libsodium
+// #include <stdlib.h>
+// #include <sodium.h>
+import "C"
+import "github.com/GoKillers/libsodium-go/support"
+
+// Seal encrypts and authenticates a message `m` using a nonce `n` and a secret key `k`,
+// and appends the ciphertext to `dst`, returning the updated slice.
+// No memory is allocated if `dst` has enough capacity.
+//
+// The message and dst may alias exactly or not at all. To reuse
+// the storage of the message for the ciphertext, use m[:0] as dst.
+func Seal(dst, m, n, k []byte) []byte {
+	support.CheckSize(n, CryptoSecretBoxNonceBytes(), "nonce")
+	support.CheckSize(k, CryptoSecretBoxKeyBytes(), "key")
+
+	ret, c := support.SliceForAppend(dst, len(m)+CryptoSecretBoxMacBytes())
+
+	C.crypto_secretbox_easy(
+		(*C.uchar)(&c[0]),
+		(*C.uchar)(support.BytePointer(m)),
+		(C.ulonglong)(len(m)),
+		(*C.uchar)(&n[0]),
+		(*C.uchar)(&k[0]))
+
+	return ret
+}
+
+// Open verifies and decrypts a ciphertext `c` using a nonce `n` and a secret key `k`,
+// and appends the message to `dst`, returning the updated slice.
+// No memory is allocated if `dst` has enough capacity.
+//
+// The ciphertext and dst may alias exactly or not at all. To reuse
+// the storage of the ciphertext for the message, use c[:0] as dst.
+// Even if the function fails, the contents of dst, up to its capacity,
+// may be overwritten.
+func Open(dst, c, n, k []byte) ([]byte, error) {
+	support.CheckSizeMin(c, CryptoSecretBoxMacBytes(), "ciphertext")
+	support.CheckSize(n, CryptoSecretBoxNonceBytes(), "nonce")
+	support.CheckSize(k, CryptoSecretBoxKeyBytes(), "key")
+
+	ret, m := support.SliceForAppend(dst, len(c)-CryptoSecretBoxMacBytes())
+
+	exit := C.crypto_secretbox_open_easy(
+		(*C.uchar)(support.BytePointer(m)),
+		(*C.uchar)(&c[0]),
+		(C.ulonglong)(len(c)),
+		(*C.uchar)(&n[0]),
+		(*C.uchar)(&k[0]))
+
+	if exit != 0 {
+		return nil, &support.VerificationError{}
+	}
+
+	return ret, nil
+}
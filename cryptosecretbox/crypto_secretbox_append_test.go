@@ -0,0 +1,64 @@
+package secretbox
+
+import (
+	"bytes"
+	"github.com/GoKillers/libsodium-go/randombytes"
+	"testing"
+)
+
+func TestSealOpen(t *testing.T) {
+	k := randombytes.RandomBytes(CryptoSecretBoxKeyBytes())
+	n := randombytes.RandomBytes(CryptoSecretBoxNonceBytes())
+	m := []byte("test message")
+
+	c := Seal([]byte("prefix"), m, n, k)
+	expected, _ := CryptoSecretBoxEasy(m, n, k)
+	if !bytes.Equal(c, append([]byte("prefix"), expected...)) {
+		t.Fatal("Seal differs from CryptoSecretBoxEasy")
+	}
+
+	// Decrypt in place
+	c = c[len("prefix"):]
+	p, err := Open(c[:0], c, n, k)
+	if err != nil || !bytes.Equal(p, m) {
+		t.Fatalf("Open failed: %v", err)
+	}
+
+	c = Seal(nil, m, n, k)
+	c[len(c)-1] ^= 1
+	if _, err = Open(nil, c, n, k); err == nil {
+		t.Fatal("Open accepted a forged ciphertext")
+	}
+}
+
+func BenchmarkSeal(b *testing.B) {
+	k := make([]byte, CryptoSecretBoxKeyBytes())
+	n := make([]byte, CryptoSecretBoxNonceBytes())
+	m := make([]byte, 1024)
+	buf := make([]byte, 0, len(m)+CryptoSecretBoxMacBytes())
+
+	b.SetBytes(int64(len(m)))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		Seal(buf, m, n, k)
+	}
+}
+
+func BenchmarkOpen(b *testing.B) {
+	k := make([]byte, CryptoSecretBoxKeyBytes())
+	n := make([]byte, CryptoSecretBoxNonceBytes())
+	c := Seal(nil, make([]byte, 1024), n, k)
+	buf := make([]byte, 0, len(c))
+
+	b.SetBytes(int64(len(c) - CryptoSecretBoxMacBytes()))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := Open(buf, c, n, k); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package secretbox
+
+import "github.com/GoKillers/libsodium-go/sodium"
+
+// CryptoSecretBoxEasyPadded pads a message to a multiple of `blockSize` bytes
+// using ISO/IEC 7816-4 padding before encrypting it with CryptoSecretBoxEasy.
+func CryptoSecretBoxEasyPadded(m []byte, n []byte, k []byte, blockSize int) ([]byte, int) {
+	p := sodium.Pad(m, blockSize)
+	defer sodium.MemZero(p)
+
+	return CryptoSecretBoxEasy(p, n, k)
+}
+
+// CryptoSecretBoxOpenEasyPadded decrypts a ciphertext created by CryptoSecretBoxEasyPadded
+// and removes the padding. A non-zero exit code is returned if either decryption fails
+// or the padding is invalid.
+func CryptoSecretBoxOpenEasyPadded(c []byte, n []byte, k []byte, blockSize int) ([]byte, int) {
+	p, exit := CryptoSecretBoxOpenEasy(c, n, k)
+	if exit != 0 {
+		return p, exit
+	}
+
+	m, err := sodium.Unpad(p, blockSize)
+	if err != nil {
+		return nil, -1
+	}
+
+	return m, 0
+}
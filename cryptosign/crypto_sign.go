@@ -5,8 +5,14 @@ package cryptosign
 // #include <sodium.h>
 import "C"
 import "github.com/GoKillers/libsodium-go/cryptobox"
+import "github.com/GoKillers/libsodium-go/sodium"
 import "github.com/GoKillers/libsodium-go/support"
 
+// Sodium should always be initialised
+func init() {
+	sodium.Initialize()
+}
+
 func CryptoSignBytes() int {
 	return int(C.crypto_sign_bytes())
 }
@@ -87,7 +93,7 @@ func CryptoSignDetached(m []byte, sk []byte) ([]byte, int) {
 	exit := int(C.crypto_sign_detached(
 		(*C.uchar)(&sig[0]),
 		(&actualSigSize),
-		(*C.uchar)(&m[0]),
+		(*C.uchar)(support.BytePointer(m)),
 		(C.ulonglong)(len(m)),
 		(*C.uchar)(&sk[0])))
 
@@ -100,7 +106,7 @@ func CryptoSignVerifyDetached(sig []byte, m []byte, pk []byte) int {
 
 	return int(C.crypto_sign_verify_detached(
 		(*C.uchar)(&sig[0]),
-		(*C.uchar)(&m[0]),
+		(*C.uchar)(support.BytePointer(m)),
 		(C.ulonglong)(len(m)),
 		(*C.uchar)(&pk[0])))
 }
@@ -0,0 +1,97 @@
+package cryptostream
+
+// #cgo pkg-config: libsodium
+// #include <stdlib.h>
+// #include <sodium.h>
+import "C"
+import "github.com/GoKillers/libsodium-go/support"
+
+// appendXOR checks the nonce and key sizes, and appends the XOR of `m`
+// with the key stream computed by `xor` to `dst`.
+func appendXOR(dst, m, n, k []byte, nonceSize, keySize int, xor func(c, m *C.uchar, mlen C.ulonglong, n, k *C.uchar)) []byte {
+	support.CheckSize(n, nonceSize, "nonce")
+	support.CheckSize(k, keySize, "key")
+
+	ret, c := support.SliceForAppend(dst, len(m))
+	if len(m) == 0 {
+		return ret
+	}
+
+	xor(
+		(*C.uchar)(&c[0]),
+		(*C.uchar)(&m[0]),
+		(C.ulonglong)(len(m)),
+		(*C.uchar)(&n[0]),
+		(*C.uchar)(&k[0]))
+
+	return ret
+}
+
+// AppendXOR encrypts or decrypts a message `m` with the default stream cipher (XSalsa20)
+// using a nonce `n` and a key `k`, and appends the result to `dst`, returning the updated slice.
+// No memory is allocated if `dst` has enough capacity.
+//
+// The message and dst may alias exactly or not at all. To reuse
+// the storage of the message for the result, use m[:0] as dst.
+func AppendXOR(dst, m, n, k []byte) []byte {
+	return appendXOR(dst, m, n, k, CryptoStreamNonceBytes(), CryptoStreamKeyBytes(),
+		func(c, m *C.uchar, mlen C.ulonglong, n, k *C.uchar) {
+			C.crypto_stream_xor(c, m, mlen, n, k)
+		})
+}
+
+// AppendChaCha20XOR is like AppendXOR, using ChaCha20 with a 64-bit nonce.
+func AppendChaCha20XOR(dst, m, n, k []byte) []byte {
+	return appendXOR(dst, m, n, k, CryptoStreamChaCha20NonceBytes(), CryptoStreamChaCha20KeyBytes(),
+		func(c, m *C.uchar, mlen C.ulonglong, n, k *C.uchar) {
+			C.crypto_stream_chacha20_xor(c, m, mlen, n, k)
+		})
+}
+
+// AppendChaCha20IETFXOR is like AppendXOR, using the IETF variant of ChaCha20.
+func AppendChaCha20IETFXOR(dst, m, n, k []byte) []byte {
+	return appendXOR(dst, m, n, k, CryptoStreamChaCha20IETFNonceBytes(), CryptoStreamChaCha20IETFKeyBytes(),
+		func(c, m *C.uchar, mlen C.ulonglong, n, k *C.uchar) {
+			C.crypto_stream_chacha20_ietf_xor(c, m, mlen, n, k)
+		})
+}
+
+// AppendXChaCha20XOR is like AppendXOR, using XChaCha20.
+func AppendXChaCha20XOR(dst, m, n, k []byte) []byte {
+	return appendXOR(dst, m, n, k, CryptoStreamXChaCha20NonceBytes(), CryptoStreamXChaCha20KeyBytes(),
+		func(c, m *C.uchar, mlen C.ulonglong, n, k *C.uchar) {
+			C.crypto_stream_xchacha20_xor(c, m, mlen, n, k)
+		})
+}
+
+// AppendSalsa20XOR is like AppendXOR, using Salsa20.
+func AppendSalsa20XOR(dst, m, n, k []byte) []byte {
+	return appendXOR(dst, m, n, k, CryptoStreamSalsa20NonceBytes(), CryptoStreamSalsa20KeyBytes(),
+		func(c, m *C.uchar, mlen C.ulonglong, n, k *C.uchar) {
+			C.crypto_stream_salsa20_xor(c, m, mlen, n, k)
+		})
+}
+
+// AppendXSalsa20XOR is like AppendXOR, using XSalsa20.
+func AppendXSalsa20XOR(dst, m, n, k []byte) []byte {
+	return appendXOR(dst, m, n, k, CryptoStreamXSalsa20NonceBytes(), CryptoStreamXSalsa20KeyBytes(),
+		func(c, m *C.uchar, mlen C.ulonglong, n, k *C.uchar) {
+			C.crypto_stream_xsalsa20_xor(c, m, mlen, n, k)
+		})
+}
+
+// AppendSalsa2012XOR is like AppendXOR, using Salsa20 reduced to 12 rounds.
+func AppendSalsa2012XOR(dst, m, n, k []byte) []byte {
+	return appendXOR(dst, m, n, k, CryptoStreamSalsa2012NonceBytes(), CryptoStreamSalsa2012KeyBytes(),
+		func(c, m *C.uchar, mlen C.ulonglong, n, k *C.uchar) {
+			C.crypto_stream_salsa2012_xor(c, m, mlen, n, k)
+		})
+}
+
+// AppendSalsa208XOR is like AppendXOR, using Salsa20 reduced to 8 rounds.
+func AppendSalsa208XOR(dst, m, n, k []byte) []byte {
+	return appendXOR(dst, m, n, k, CryptoStreamSalsa208NonceBytes(), CryptoStreamSalsa208KeyBytes(),
+		func(c, m *C.uchar, mlen C.ulonglong, n, k *C.uchar) {
+			C.crypto_stream_salsa208_xor(c, m, mlen, n, k)
+		})
+}
@@ -0,0 +1,64 @@
+package cryptostream
+
+import (
+	"bytes"
+	"github.com/GoKillers/libsodium-go/randombytes"
+	"testing"
+)
+
+func TestAppendXOR(t *testing.T) {
+	tests := []struct {
+		name      string
+		append    func(dst, m, n, k []byte) []byte
+		xor       func(m, n, k []byte) ([]byte, int)
+		keySize   int
+		nonceSize int
+	}{
+		{"Default", AppendXOR, CryptoStreamXOR, CryptoStreamKeyBytes(), CryptoStreamNonceBytes()},
+		{"ChaCha20", AppendChaCha20XOR, CryptoStreamChaCha20XOR, CryptoStreamChaCha20KeyBytes(), CryptoStreamChaCha20NonceBytes()},
+		{"ChaCha20IETF", AppendChaCha20IETFXOR, CryptoStreamChaCha20IETFXOR, CryptoStreamChaCha20IETFKeyBytes(), CryptoStreamChaCha20IETFNonceBytes()},
+		{"XChaCha20", AppendXChaCha20XOR, CryptoStreamXChaCha20XOR, CryptoStreamXChaCha20KeyBytes(), CryptoStreamXChaCha20NonceBytes()},
+		{"Salsa20", AppendSalsa20XOR, CryptoStreamSalsa20XOR, CryptoStreamSalsa20KeyBytes(), CryptoStreamSalsa20NonceBytes()},
+		{"XSalsa20", AppendXSalsa20XOR, CryptoStreamXSalsa20XOR, CryptoStreamXSalsa20KeyBytes(), CryptoStreamXSalsa20NonceBytes()},
+		{"Salsa2012", AppendSalsa2012XOR, CryptoStreamSalsa2012XOR, CryptoStreamSalsa2012KeyBytes(), CryptoStreamSalsa2012NonceBytes()},
+		{"Salsa208", AppendSalsa208XOR, CryptoStreamSalsa208XOR, CryptoStreamSalsa208KeyBytes(), CryptoStreamSalsa208NonceBytes()},
+	}
+
+	m := randombytes.RandomBytes(100)
+
+	for _, test := range tests {
+		k := randombytes.RandomBytes(test.keySize)
+		n := randombytes.RandomBytes(test.nonceSize)
+		expected, _ := test.xor(m, n, k)
+
+		c := test.append([]byte("prefix"), m, n, k)
+		if !bytes.Equal(c, append([]byte("prefix"), expected...)) {
+			t.Errorf("%s: output differs from the allocating variant", test.name)
+		}
+
+		// Decrypt in place
+		c = c[len("prefix"):]
+		if p := test.append(c[:0], c, n, k); !bytes.Equal(p, m) {
+			t.Errorf("%s: decryption failed", test.name)
+		}
+
+		if c := test.append(nil, nil, n, k); len(c) != 0 {
+			t.Errorf("%s: non-empty output for an empty message", test.name)
+		}
+	}
+}
+
+func BenchmarkAppendXChaCha20XOR(b *testing.B) {
+	k := make([]byte, CryptoStreamXChaCha20KeyBytes())
+	n := make([]byte, CryptoStreamXChaCha20NonceBytes())
+	m := make([]byte, 1024)
+	buf := make([]byte, 0, len(m))
+
+	b.SetBytes(int64(len(m)))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		AppendXChaCha20XOR(buf, m, n, k)
+	}
+}
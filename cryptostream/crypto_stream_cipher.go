@@ -0,0 +1,171 @@
+package cryptostream
+
+// #cgo pkg-config: libsodium
+// #include <stdlib.h>
+// #include <sodium.h>
+import "C"
+import (
+	"crypto/cipher"
+	"github.com/GoKillers/libsodium-go/support"
+)
+
+// blockSize is the size of a keystream block of all supported ciphers.
+const blockSize = 64
+
+// xorFunc encrypts `mlen` bytes of `m` into `c`, starting at block `ic` of the keystream.
+type xorFunc func(c, m *C.uchar, mlen C.ulonglong, n *C.uchar, ic uint64, k *C.uchar)
+
+// stream implements cipher.Stream on top of a stream cipher with a block counter.
+type stream struct {
+	xor      xorFunc
+	key      []byte
+	nonce    []byte
+	counter  uint64 // Block containing the next keystream byte
+	offset   int    // Offset of the next keystream byte in the block
+	maxBlock uint64 // Number of available keystream blocks, 0 if unlimited
+}
+
+// newStream returns a stream after validating and copying the key and nonce.
+func newStream(xor xorFunc, key, nonce []byte, keySize, nonceSize int, maxBlock uint64) (*stream, error) {
+	if len(key) != keySize {
+		return nil, support.KeySizeError(len(key))
+	}
+
+	if len(nonce) != nonceSize {
+		return nil, support.NonceSizeError(len(nonce))
+	}
+
+	s := &stream{
+		xor:      xor,
+		key:      make([]byte, keySize),
+		nonce:    make([]byte, nonceSize),
+		maxBlock: maxBlock,
+	}
+	copy(s.key, key)
+	copy(s.nonce, nonce)
+
+	return s, nil
+}
+
+// XORKeyStream XORs each byte in the given slice with a byte from the cipher's key stream.
+// Dst and src must overlap entirely or not at all.
+// It panics if len(dst) < len(src), or if the key stream is exhausted.
+func (s *stream) XORKeyStream(dst, src []byte) {
+	if len(dst) < len(src) {
+		panic("cryptostream: output smaller than input")
+	}
+
+	if len(src) == 0 {
+		return
+	}
+
+	if s.maxBlock > 0 {
+		blocks := (uint64(s.offset) + uint64(len(src)) + blockSize - 1) / blockSize
+		if s.counter+blocks > s.maxBlock || s.counter+blocks < s.counter {
+			panic("cryptostream: key stream exhausted")
+		}
+	}
+
+	// Finish a partially used block
+	if s.offset > 0 {
+		var block [blockSize]byte
+		s.xor(
+			(*C.uchar)(&block[0]),
+			(*C.uchar)(&block[0]),
+			C.ulonglong(blockSize),
+			(*C.uchar)(&s.nonce[0]),
+			s.counter,
+			(*C.uchar)(&s.key[0]))
+
+		ks := block[s.offset:]
+		n := len(ks)
+		if n > len(src) {
+			n = len(src)
+		}
+
+		for i := 0; i < n; i++ {
+			dst[i] = src[i] ^ ks[i]
+		}
+
+		s.advance(n)
+		dst, src = dst[n:], src[n:]
+
+		if len(src) == 0 {
+			return
+		}
+	}
+
+	// Process the remaining data starting at a block boundary
+	s.xor(
+		(*C.uchar)(&dst[0]),
+		(*C.uchar)(&src[0]),
+		C.ulonglong(len(src)),
+		(*C.uchar)(&s.nonce[0]),
+		s.counter,
+		(*C.uchar)(&s.key[0]))
+
+	s.advance(len(src))
+}
+
+// advance moves the keystream position forward by n bytes.
+func (s *stream) advance(n int) {
+	pos := s.offset + n
+	s.counter += uint64(pos / blockSize)
+	s.offset = pos % blockSize
+}
+
+// NewChaCha20 returns a cipher.Stream for ChaCha20 with a 64-bit nonce.
+// The key must be CryptoStreamChaCha20KeyBytes() bytes long,
+// and the nonce CryptoStreamChaCha20NonceBytes() bytes.
+func NewChaCha20(key, nonce []byte) (cipher.Stream, error) {
+	return newStream(
+		func(c, m *C.uchar, mlen C.ulonglong, n *C.uchar, ic uint64, k *C.uchar) {
+			C.crypto_stream_chacha20_xor_ic(c, m, mlen, n, C.uint64_t(ic), k)
+		},
+		key, nonce, CryptoStreamChaCha20KeyBytes(), CryptoStreamChaCha20NonceBytes(), 0)
+}
+
+// NewChaCha20IETF returns a cipher.Stream for the IETF variant of ChaCha20.
+// The key must be CryptoStreamChaCha20IETFKeyBytes() bytes long,
+// and the nonce CryptoStreamChaCha20IETFNonceBytes() bytes.
+// The key stream is limited to 2^32 blocks of 64 bytes.
+func NewChaCha20IETF(key, nonce []byte) (cipher.Stream, error) {
+	return newStream(
+		func(c, m *C.uchar, mlen C.ulonglong, n *C.uchar, ic uint64, k *C.uchar) {
+			C.crypto_stream_chacha20_ietf_xor_ic(c, m, mlen, n, C.uint32_t(ic), k)
+		},
+		key, nonce, CryptoStreamChaCha20IETFKeyBytes(), CryptoStreamChaCha20IETFNonceBytes(), 1<<32)
+}
+
+// NewXChaCha20 returns a cipher.Stream for XChaCha20.
+// The key must be CryptoStreamXChaCha20KeyBytes() bytes long,
+// and the nonce CryptoStreamXChaCha20NonceBytes() bytes.
+func NewXChaCha20(key, nonce []byte) (cipher.Stream, error) {
+	return newStream(
+		func(c, m *C.uchar, mlen C.ulonglong, n *C.uchar, ic uint64, k *C.uchar) {
+			C.crypto_stream_xchacha20_xor_ic(c, m, mlen, n, C.uint64_t(ic), k)
+		},
+		key, nonce, CryptoStreamXChaCha20KeyBytes(), CryptoStreamXChaCha20NonceBytes(), 0)
+}
+
+// NewSalsa20 returns a cipher.Stream for Salsa20.
+// The key must be CryptoStreamSalsa20KeyBytes() bytes long,
+// and the nonce CryptoStreamSalsa20NonceBytes() bytes.
+func NewSalsa20(key, nonce []byte) (cipher.Stream, error) {
+	return newStream(
+		func(c, m *C.uchar, mlen C.ulonglong, n *C.uchar, ic uint64, k *C.uchar) {
+			C.crypto_stream_salsa20_xor_ic(c, m, mlen, n, C.uint64_t(ic), k)
+		},
+		key, nonce, CryptoStreamSalsa20KeyBytes(), CryptoStreamSalsa20NonceBytes(), 0)
+}
+
+// NewXSalsa20 returns a cipher.Stream for XSalsa20.
+// The key must be CryptoStreamXSalsa20KeyBytes() bytes long,
+// and the nonce CryptoStreamXSalsa20NonceBytes() bytes.
+func NewXSalsa20(key, nonce []byte) (cipher.Stream, error) {
+	return newStream(
+		func(c, m *C.uchar, mlen C.ulonglong, n *C.uchar, ic uint64, k *C.uchar) {
+			C.crypto_stream_xsalsa20_xor_ic(c, m, mlen, n, C.uint64_t(ic), k)
+		},
+		key, nonce, CryptoStreamXSalsa20KeyBytes(), CryptoStreamXSalsa20NonceBytes(), 0)
+}
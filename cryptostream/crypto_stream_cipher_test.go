@@ -0,0 +1,69 @@
+package cryptostream
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"github.com/GoKillers/libsodium-go/randombytes"
+	"io"
+	"testing"
+)
+
+type streamTest struct {
+	name      string
+	new       func(key, nonce []byte) (cipher.Stream, error)
+	xor       func(m, n, k []byte) ([]byte, int)
+	keySize   int
+	nonceSize int
+}
+
+var streamTests = []streamTest{
+	{"ChaCha20", NewChaCha20, CryptoStreamChaCha20XOR, CryptoStreamChaCha20KeyBytes(), CryptoStreamChaCha20NonceBytes()},
+	{"ChaCha20IETF", NewChaCha20IETF, CryptoStreamChaCha20IETFXOR, CryptoStreamChaCha20IETFKeyBytes(), CryptoStreamChaCha20IETFNonceBytes()},
+	{"XChaCha20", NewXChaCha20, CryptoStreamXChaCha20XOR, CryptoStreamXChaCha20KeyBytes(), CryptoStreamXChaCha20NonceBytes()},
+	{"Salsa20", NewSalsa20, CryptoStreamSalsa20XOR, CryptoStreamSalsa20KeyBytes(), CryptoStreamSalsa20NonceBytes()},
+	{"XSalsa20", NewXSalsa20, CryptoStreamXSalsa20XOR, CryptoStreamXSalsa20KeyBytes(), CryptoStreamXSalsa20NonceBytes()},
+}
+
+func TestStream(t *testing.T) {
+	m := randombytes.RandomBytes(1000)
+
+	for _, test := range streamTests {
+		key := randombytes.RandomBytes(test.keySize)
+		nonce := randombytes.RandomBytes(test.nonceSize)
+		expected, _ := test.xor(m, nonce, key)
+
+		// Encrypt in pieces of varying sizes
+		s, err := test.new(key, nonce)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+
+		c := make([]byte, len(m))
+		for i, n := 0, 0; i < len(m); i, n = i+n, n+7 {
+			end := i + n
+			if end > len(m) {
+				end = len(m)
+			}
+			s.XORKeyStream(c[i:end], m[i:end])
+		}
+
+		if !bytes.Equal(c, expected) {
+			t.Errorf("%s: incorrect key stream", test.name)
+		}
+
+		// In-place decryption through a cipher.StreamReader
+		s, _ = test.new(key, nonce)
+		p, _ := io.ReadAll(cipher.StreamReader{S: s, R: bytes.NewReader(c)})
+		if !bytes.Equal(p, m) {
+			t.Errorf("%s: StreamReader decryption failed", test.name)
+		}
+
+		// Invalid sizes
+		if _, err := test.new(key[1:], nonce); err == nil {
+			t.Errorf("%s: invalid key size accepted", test.name)
+		}
+		if _, err := test.new(key, nonce[1:]); err == nil {
+			t.Errorf("%s: invalid nonce size accepted", test.name)
+		}
+	}
+}
@@ -0,0 +1,199 @@
+package cryptostream
+
+import (
+	"errors"
+	"github.com/GoKillers/libsodium-go/support"
+	"io"
+	"os"
+)
+
+// ReaderWriterAt is the storage underlying a SeekableStream, such as an *os.File.
+type ReaderWriterAt interface {
+	io.ReaderAt
+	io.WriterAt
+}
+
+// xorICFunc is the signature of the CryptoStream*XORIC functions, with a 64-bit counter.
+type xorICFunc func(m []byte, n []byte, ic uint64, k []byte) ([]byte, int)
+
+// SeekableStream provides random access to data stored encrypted with a stream cipher.
+// Any byte range can be read or written independently, because the block counter
+// of the key stream is computed from the offset.
+//
+// A stream cipher provides confidentiality only: the data is not authenticated,
+// and each key and nonce pair must only ever be used for a single blob of data.
+// Overwriting data in place reuses the key stream, so an attacker who sees both
+// the old and new ciphertext learns the XOR of the old and new plaintext.
+//
+// A SeekableStream implements io.ReaderAt, io.WriterAt and io.ReadWriteSeeker.
+// The ReadAt and WriteAt methods are safe for concurrent use if those of the
+// underlying storage are, but Read, Write and Seek share a position and are not.
+type SeekableStream struct {
+	f       ReaderWriterAt
+	xor     xorICFunc
+	key     []byte
+	nonce   []byte
+	maxSize int64 // Maximum size of the data, 0 if unlimited
+	pos     int64
+}
+
+// newSeekableStream returns a SeekableStream after validating and copying the key and nonce.
+func newSeekableStream(f ReaderWriterAt, xor xorICFunc, key, nonce []byte, keySize, nonceSize int, maxSize int64) (*SeekableStream, error) {
+	support.NilPanic(f == nil, "storage")
+
+	if len(key) != keySize {
+		return nil, support.KeySizeError(len(key))
+	}
+
+	if len(nonce) != nonceSize {
+		return nil, support.NonceSizeError(len(nonce))
+	}
+
+	s := &SeekableStream{
+		f:       f,
+		xor:     xor,
+		key:     make([]byte, keySize),
+		nonce:   make([]byte, nonceSize),
+		maxSize: maxSize,
+	}
+	copy(s.key, key)
+	copy(s.nonce, nonce)
+
+	return s, nil
+}
+
+// NewSeekableXChaCha20 returns a SeekableStream that encrypts the data in `f` using XChaCha20.
+// The key must be CryptoStreamXChaCha20KeyBytes() bytes long,
+// and the nonce CryptoStreamXChaCha20NonceBytes() bytes.
+func NewSeekableXChaCha20(f ReaderWriterAt, key, nonce []byte) (*SeekableStream, error) {
+	return newSeekableStream(f, CryptoStreamXChaCha20XORIC, key, nonce,
+		CryptoStreamXChaCha20KeyBytes(), CryptoStreamXChaCha20NonceBytes(), 0)
+}
+
+// NewSeekableChaCha20IETF returns a SeekableStream that encrypts the data in `f`
+// using the IETF variant of ChaCha20.
+// The key must be CryptoStreamChaCha20IETFKeyBytes() bytes long,
+// and the nonce CryptoStreamChaCha20IETFNonceBytes() bytes.
+// Because of its 32-bit block counter, the data is limited to 256 GiB.
+func NewSeekableChaCha20IETF(f ReaderWriterAt, key, nonce []byte) (*SeekableStream, error) {
+	xor := func(m []byte, n []byte, ic uint64, k []byte) ([]byte, int) {
+		return CryptoStreamChaCha20IETFXORIC(m, n, uint32(ic), k)
+	}
+
+	return newSeekableStream(f, xor, key, nonce,
+		CryptoStreamChaCha20IETFKeyBytes(), CryptoStreamChaCha20IETFNonceBytes(), blockSize<<32)
+}
+
+// checkRange returns an error if a range of `n` bytes at offset `off` can not be encrypted.
+func (s *SeekableStream) checkRange(off int64, n int) error {
+	if off < 0 {
+		return errors.New("cryptostream: negative offset")
+	}
+
+	if s.maxSize > 0 && (off > s.maxSize || int64(n) > s.maxSize-off) {
+		return errors.New("cryptostream: range exceeds the key stream")
+	}
+
+	return nil
+}
+
+// xorAt XORs `p` in place with the key stream starting at offset `off`.
+func (s *SeekableStream) xorAt(p []byte, off int64) {
+	if len(p) == 0 {
+		return
+	}
+
+	// Start at the block containing the offset, and discard
+	// the key stream preceding the offset in that block.
+	skip := int(off % blockSize)
+	buf := make([]byte, skip+len(p))
+	copy(buf[skip:], p)
+
+	c, _ := s.xor(buf, s.nonce, uint64(off/blockSize), s.key)
+	copy(p, c[skip:])
+}
+
+// ReadAt reads and decrypts len(p) bytes starting at offset `off`.
+// See io.ReaderAt for details.
+func (s *SeekableStream) ReadAt(p []byte, off int64) (int, error) {
+	if err := s.checkRange(off, len(p)); err != nil {
+		return 0, err
+	}
+
+	n, err := s.f.ReadAt(p, off)
+	s.xorAt(p[:n], off)
+
+	return n, err
+}
+
+// WriteAt encrypts and writes len(p) bytes starting at offset `off`.
+// The contents of p are not modified.
+// See io.WriterAt for details.
+func (s *SeekableStream) WriteAt(p []byte, off int64) (int, error) {
+	if err := s.checkRange(off, len(p)); err != nil {
+		return 0, err
+	}
+
+	c := make([]byte, len(p))
+	copy(c, p)
+	s.xorAt(c, off)
+
+	return s.f.WriteAt(c, off)
+}
+
+// Read reads and decrypts up to len(p) bytes at the current position.
+func (s *SeekableStream) Read(p []byte) (int, error) {
+	n, err := s.ReadAt(p, s.pos)
+	s.pos += int64(n)
+
+	// A short read at the end of the data is not an error for io.Reader
+	if err == io.EOF && n > 0 {
+		err = nil
+	}
+
+	return n, err
+}
+
+// Write encrypts and writes len(p) bytes at the current position.
+func (s *SeekableStream) Write(p []byte) (int, error) {
+	n, err := s.WriteAt(p, s.pos)
+	s.pos += int64(n)
+
+	return n, err
+}
+
+// Seek sets the position for the next Read or Write. See io.Seeker for details.
+// Seeking relative to the end requires the underlying storage to have
+// a Stat method, like *os.File.
+func (s *SeekableStream) Seek(offset int64, whence int) (int64, error) {
+	var pos int64
+
+	switch whence {
+	case io.SeekStart:
+		pos = offset
+	case io.SeekCurrent:
+		pos = s.pos + offset
+	case io.SeekEnd:
+		f, ok := s.f.(interface{ Stat() (os.FileInfo, error) })
+		if !ok {
+			return s.pos, errors.New("cryptostream: size of the underlying storage is unknown")
+		}
+
+		fi, err := f.Stat()
+		if err != nil {
+			return s.pos, err
+		}
+
+		pos = fi.Size() + offset
+	default:
+		return s.pos, errors.New("cryptostream: invalid whence")
+	}
+
+	if pos < 0 {
+		return s.pos, errors.New("cryptostream: negative position")
+	}
+
+	s.pos = pos
+
+	return pos, nil
+}
@@ -0,0 +1,89 @@
+package cryptostream
+
+import (
+	"bytes"
+	"github.com/GoKillers/libsodium-go/randombytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSeekableStream(t *testing.T) {
+	f, err := os.Create(filepath.Join(t.TempDir(), "blob"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	key := CryptoStreamXChaCha20Keygen()
+	nonce := randombytes.RandomBytes(CryptoStreamXChaCha20NonceBytes())
+	m := randombytes.RandomBytes(10000)
+
+	s, err := NewSeekableXChaCha20(f, key, nonce)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Write the data in unaligned pieces, out of order
+	for _, r := range [][2]int{{5000, 10000}, {0, 1}, {1, 63}, {63, 130}, {130, 5000}} {
+		if _, err := s.WriteAt(m[r[0]:r[1]], int64(r[0])); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The stored data matches the one-shot encryption
+	c, _ := CryptoStreamXChaCha20XOR(m, nonce, key)
+	stored, _ := os.ReadFile(f.Name())
+	if !bytes.Equal(stored, c) {
+		t.Fatal("Stored data is not encrypted correctly")
+	}
+
+	// Read unaligned ranges
+	for _, r := range [][2]int{{0, 10000}, {1, 2}, {63, 65}, {4097, 9999}} {
+		p := make([]byte, r[1]-r[0])
+		if _, err := s.ReadAt(p, int64(r[0])); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(p, m[r[0]:r[1]]) {
+			t.Fatalf("Incorrect data read at %v", r)
+		}
+	}
+
+	// Seek and read to the end
+	if pos, err := s.Seek(-100, io.SeekEnd); err != nil || pos != 9900 {
+		t.Fatalf("Seek failed: %v, %v", pos, err)
+	}
+	p, err := io.ReadAll(s)
+	if err != nil || !bytes.Equal(p, m[9900:]) {
+		t.Fatalf("Reading after seek failed: %v", err)
+	}
+}
+
+func TestSeekableStreamIETFLimit(t *testing.T) {
+	f, err := os.Create(filepath.Join(t.TempDir(), "blob"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	key := CryptoStreamChaCha20IETFKeygen()
+	nonce := make([]byte, CryptoStreamChaCha20IETFNonceBytes())
+
+	s, err := NewSeekableChaCha20IETF(f, key, nonce)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.WriteAt([]byte{1}, 64<<32); err == nil {
+		t.Error("Writing beyond the key stream succeeded")
+	}
+
+	if _, err := s.WriteAt([]byte{1, 2}, 64<<32-1); err == nil {
+		t.Error("Writing across the end of the key stream succeeded")
+	}
+
+	if _, err := s.WriteAt([]byte{1}, 64); err != nil {
+		t.Errorf("Writing failed: %v", err)
+	}
+}
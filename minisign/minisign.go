@@ -0,0 +1,270 @@
+// Package minisign implements signing and verification compatible with minisign.
+//
+// Keys are Ed25519 key pairs with a random 8-byte key ID. A secret key file is
+// normally encrypted with a key derived from a password with scrypt, and contains
+// a BLAKE2b checksum that detects a wrong password.
+//
+// Files are signed in the prehashed mode: the Ed25519 signature covers the
+// BLAKE2b-512 hash of the file. Signatures carry a trusted comment, which is
+// covered by a second, global signature, and an untrusted comment, which is not
+// authenticated. Legacy signatures of the unhashed file are accepted by Verify.
+package minisign
+
+// #cgo pkg-config: libsodium
+// #include <stdlib.h>
+// #include <sodium.h>
+import "C"
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"github.com/GoKillers/libsodium-go/cryptogenerichash"
+	"github.com/GoKillers/libsodium-go/cryptosign"
+	"github.com/GoKillers/libsodium-go/randombytes"
+	"github.com/GoKillers/libsodium-go/sodium"
+	"github.com/GoKillers/libsodium-go/support"
+	"strings"
+	"unsafe"
+)
+
+// Sodium should always be initialised
+func init() {
+	sodium.Initialize()
+}
+
+// Sizes of keys and signatures.
+const (
+	KeyIDBytes     = 8
+	PublicKeyBytes = C.crypto_sign_PUBLICKEYBYTES
+	SecretKeyBytes = C.crypto_sign_SECRETKEYBYTES
+	SignatureBytes = C.crypto_sign_BYTES
+)
+
+// Default scrypt limits for encrypting a secret key, as used by minisign.
+// They are also the highest limits accepted by ParsePrivateKey.
+const (
+	DefaultOpsLimit = C.crypto_pwhash_scryptsalsa208sha256_OPSLIMIT_SENSITIVE
+	DefaultMemLimit = C.crypto_pwhash_scryptsalsa208sha256_MEMLIMIT_SENSITIVE
+)
+
+// Algorithm identifiers.
+const (
+	algEd25519    = "Ed" // Signature of the file
+	algPrehashed  = "ED" // Signature of the BLAKE2b-512 hash of the file
+	kdfScrypt     = "Sc"
+	kdfNone       = "\x00\x00"
+	checksumBLAKE = "B2"
+	saltBytes     = C.crypto_pwhash_scryptsalsa208sha256_SALTBYTES
+	checksumBytes = 32
+)
+
+const untrustedPrefix = "untrusted comment: "
+
+// KeyID identifies the key pair that made a signature.
+type KeyID [KeyIDBytes]byte
+
+// String returns the key ID as minisign displays it: the little-endian
+// number in upper-case hexadecimal.
+func (id KeyID) String() string {
+	return fmt.Sprintf("%016X", binary.LittleEndian.Uint64(id[:]))
+}
+
+// PublicKey is a minisign public key.
+type PublicKey struct {
+	ID  KeyID
+	Key [PublicKeyBytes]byte
+}
+
+// ParsePublicKey parses a public key from the contents of a public key file,
+// or from the base64 line of such a file, as shown by minisign -R.
+func ParsePublicKey(s string) (*PublicKey, error) {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, untrustedPrefix) {
+		_, s, _ = strings.Cut(s, "\n")
+		s = strings.TrimSpace(s)
+	}
+
+	b, err := base64.StdEncoding.DecodeString(s)
+	if err != nil || len(b) != 2+KeyIDBytes+PublicKeyBytes || string(b[:2]) != algEd25519 {
+		return nil, errors.New("minisign: invalid public key")
+	}
+
+	pk := &PublicKey{}
+	copy(pk.ID[:], b[2:])
+	copy(pk.Key[:], b[2+KeyIDBytes:])
+
+	return pk, nil
+}
+
+// String returns the base64 encoding of the public key.
+func (pk *PublicKey) String() string {
+	b := append([]byte(algEd25519), pk.ID[:]...)
+	return base64.StdEncoding.EncodeToString(append(b, pk.Key[:]...))
+}
+
+// MarshalText returns the contents of a public key file.
+func (pk *PublicKey) MarshalText() ([]byte, error) {
+	return []byte(untrustedPrefix + "minisign public key " + pk.ID.String() + "\n" + pk.String() + "\n"), nil
+}
+
+// PrivateKey is a minisign secret key.
+type PrivateKey struct {
+	ID  KeyID
+	key []byte
+}
+
+// GenerateKey generates a key pair with a random key ID.
+func GenerateKey() (*PublicKey, *PrivateKey) {
+	sk, pk, _ := cryptosign.CryptoSignKeyPair()
+
+	priv := &PrivateKey{key: sk}
+	randombytes.RandomBytesBuf(priv.ID[:])
+
+	pub := &PublicKey{ID: priv.ID}
+	copy(pub.Key[:], pk)
+
+	return pub, priv
+}
+
+// Public returns the public key of the secret key.
+func (sk *PrivateKey) Public() *PublicKey {
+	pk := &PublicKey{ID: sk.ID}
+	copy(pk.Key[:], sk.key[SecretKeyBytes-PublicKeyBytes:])
+
+	return pk
+}
+
+// checksum returns the checksum of a secret key: BLAKE2b-256(algorithm || key ID || secret key).
+func (sk *PrivateKey) checksum() []byte {
+	m := append(append([]byte(algEd25519), sk.ID[:]...), sk.key...)
+	defer sodium.MemZero(m)
+
+	h, _ := generichash.CryptoGenericHash(checksumBytes, m, nil)
+
+	return h
+}
+
+// keystream derives the stream that encrypts a secret key from a password.
+func keystream(password, salt []byte, opsLimit, memLimit uint64) ([]byte, error) {
+	out := make([]byte, KeyIDBytes+SecretKeyBytes+checksumBytes)
+
+	exit := C.crypto_pwhash_scryptsalsa208sha256(
+		(*C.uchar)(&out[0]),
+		(C.ulonglong)(len(out)),
+		(*C.char)(unsafe.Pointer(support.BytePointer(password))),
+		(C.ulonglong)(len(password)),
+		(*C.uchar)(&salt[0]),
+		(C.ulonglong)(opsLimit),
+		(C.size_t)(memLimit))
+
+	if exit != 0 {
+		return nil, errors.New("minisign: scrypt failed")
+	}
+
+	return out, nil
+}
+
+// MarshalText returns the contents of an unencrypted secret key file,
+// as created by minisign -W.
+func (sk *PrivateKey) MarshalText() ([]byte, error) {
+	return sk.marshal(kdfNone, make([]byte, saltBytes), 0, 0, nil), nil
+}
+
+// Encrypt returns the contents of a secret key file, encrypted with a password.
+// The scrypt limits are stored in the file; minisign uses DefaultOpsLimit and DefaultMemLimit,
+// and ParsePrivateKey rejects higher limits.
+func (sk *PrivateKey) Encrypt(password []byte, opsLimit, memLimit uint64) ([]byte, error) {
+	salt := randombytes.RandomBytes(saltBytes)
+
+	stream, err := keystream(password, salt, opsLimit, memLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer sodium.MemZero(stream)
+
+	return sk.marshal(kdfScrypt, salt, opsLimit, memLimit, stream), nil
+}
+
+// marshal returns a secret key file, with the key ID, secret key and checksum XORed with `stream` if not nil.
+func (sk *PrivateKey) marshal(kdf string, salt []byte, opsLimit, memLimit uint64, stream []byte) []byte {
+	b := []byte(algEd25519 + kdf + checksumBLAKE)
+	b = append(b, salt...)
+	b = binary.LittleEndian.AppendUint64(b, opsLimit)
+	b = binary.LittleEndian.AppendUint64(b, memLimit)
+
+	secret := len(b)
+	b = append(b, sk.ID[:]...)
+	b = append(b, sk.key...)
+	b = append(b, sk.checksum()...)
+	defer sodium.MemZero(b)
+
+	for i := range stream {
+		b[secret+i] ^= stream[i]
+	}
+
+	comment := untrustedPrefix + "minisign secret key\n"
+	if stream != nil {
+		comment = untrustedPrefix + "minisign encrypted secret key\n"
+	}
+
+	return []byte(comment + base64.StdEncoding.EncodeToString(b) + "\n")
+}
+
+// ParsePrivateKey parses the contents of a secret key file, decrypting it with
+// a password if it is encrypted. The password is ignored for an unencrypted key.
+// Scrypt limits above DefaultOpsLimit and DefaultMemLimit are rejected, which
+// bounds the time and memory an untrusted file can make the parser spend.
+func ParsePrivateKey(data, password []byte) (*PrivateKey, error) {
+	invalid := errors.New("minisign: invalid secret key")
+
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[0], untrustedPrefix) {
+		return nil, invalid
+	}
+
+	b, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[1]))
+	if err != nil {
+		return nil, invalid
+	}
+	defer sodium.MemZero(b)
+
+	header := 6 + saltBytes + 16
+	if len(b) != header+KeyIDBytes+SecretKeyBytes+checksumBytes ||
+		string(b[:2]) != algEd25519 || string(b[4:6]) != checksumBLAKE {
+		return nil, invalid
+	}
+
+	switch string(b[2:4]) {
+	case kdfScrypt:
+		salt := b[6 : 6+saltBytes]
+		opsLimit := binary.LittleEndian.Uint64(b[6+saltBytes:])
+		memLimit := binary.LittleEndian.Uint64(b[6+saltBytes+8:])
+		if opsLimit > DefaultOpsLimit || memLimit > DefaultMemLimit {
+			return nil, errors.New("minisign: scrypt limits too high")
+		}
+
+		stream, err := keystream(password, salt, opsLimit, memLimit)
+		if err != nil {
+			return nil, err
+		}
+		defer sodium.MemZero(stream)
+
+		for i := range stream {
+			b[header+i] ^= stream[i]
+		}
+	case kdfNone:
+	default:
+		return nil, invalid
+	}
+
+	sk := &PrivateKey{key: make([]byte, SecretKeyBytes)}
+	copy(sk.ID[:], b[header:])
+	copy(sk.key, b[header+KeyIDBytes:])
+
+	if sodium.MemCmp(sk.checksum(), b[header+KeyIDBytes+SecretKeyBytes:], checksumBytes) != 0 {
+		return nil, errors.New("minisign: wrong password or corrupted secret key")
+	}
+
+	return sk, nil
+}
@@ -0,0 +1,173 @@
+package minisign
+
+import (
+	"bytes"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// The key pair, message and legacy signature in testdata were made with the
+// minisign tool 0.9; see testdata/README. The public key and signatures of
+// "test" below were made with the minisign tool 0.10 by its author, and are
+// copied from the tests of github.com/jedisct1/go-minisign (MIT license).
+const (
+	testPublicKey = "untrusted comment: minisign public key E7620F1842B4E81F\n" +
+		"RWQf6LRCGA9i53mlYecO4IzT51TGPpvWucNSCh1CBM0QTaLn73Y7GFO3\n"
+
+	testMessage = "test"
+
+	testSignature = "untrusted comment: signature from minisign secret key\n" +
+		"RUQf6LRCGA9i559r3g7V1qNyJDApGip8MfqcadIgT9CuhV3EMhHoN1mGTkUidF/z7SrlQgXdy8ofjb7bNJJylDOocrCo8KLzZwo=\n" +
+		"trusted comment: timestamp:1635443258\tfile:test\thashed\n" +
+		"/cj37GK60vryibFn+ftOgbCvW9NKhKYgjVpFFQUcWPAnjO23wrvVDTt7cloNC06maoBli9q6qwZDXXoaxweICQ==\n"
+
+	testLegacySignature = "untrusted comment: signature from minisign secret key\n" +
+		"RWQf6LRCGA9i59SLOFxz6NxvASXDJeRtuZykwQepbDEGt87ig1BNpWaVWuNrm73YiIiJbq71Wi+dP9eKL8OC351vwIasSSbXxwA=\n" +
+		"trusted comment: timestamp:1635442742\tfile:test\n" +
+		"0YteLgV960ia80vnA/fHbvkyjl/IoP/HNOCaZfrF0CdhAlp7ok+Tpkya+VpWPX5C/Is3q8a/kEDSY7fBmmgJCg==\n"
+)
+
+func readFile(t *testing.T, name string) []byte {
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return data
+}
+
+func TestVectors(t *testing.T) {
+	pk, err := ParsePublicKey(testPublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if pk.ID.String() != "E7620F1842B4E81F" {
+		t.Fatalf("Wrong key ID %s", pk.ID)
+	}
+
+	for _, text := range []string{testSignature, testLegacySignature} {
+		s, err := ParseSignature([]byte(text))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if b, _ := s.MarshalText(); string(b) != text {
+			t.Fatal("Signature encoding differs")
+		}
+
+		if err := Verify(pk, strings.NewReader(testMessage), s); err != nil {
+			t.Fatalf("Verification of %s signature failed: %v", s.Algorithm, err)
+		}
+
+		if err := Verify(pk, strings.NewReader("other message"), s); err != ErrInvalidSignature {
+			t.Fatalf("Signature of another message accepted: %v", err)
+		}
+
+		s.TrustedComment = "timestamp:1800000000"
+		if err := Verify(pk, strings.NewReader(testMessage), s); err != ErrInvalidSignature {
+			t.Fatalf("Modified trusted comment accepted: %v", err)
+		}
+	}
+}
+
+func TestKeyPairFiles(t *testing.T) {
+	text := readFile(t, "minisign.pub")
+	pk, err := ParsePublicKey(string(text))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if pk.ID.String() != "C373193807678450" {
+		t.Fatalf("Wrong key ID %s", pk.ID)
+	}
+
+	if b, _ := pk.MarshalText(); !bytes.Equal(b, text) {
+		t.Fatal("Public key encoding differs")
+	}
+
+	sk, err := ParsePrivateKey(readFile(t, "minisign.key"), []byte("correct horse battery staple"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if *sk.Public() != *pk {
+		t.Fatal("Secret key does not match public key")
+	}
+
+	if _, err := ParsePrivateKey(readFile(t, "minisign.key"), []byte("wrong")); err == nil {
+		t.Fatal("Wrong password accepted")
+	}
+
+	message := readFile(t, "message.txt")
+	s, err := ParseSignature(readFile(t, "message.txt.minisig"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Verify(pk, bytes.NewReader(message), s); err != nil {
+		t.Fatal(err)
+	}
+
+	if s, err = Sign(sk, bytes.NewReader(message), ""); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Verify(pk, bytes.NewReader(message), s); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestKeyFiles(t *testing.T) {
+	pk, sk := GenerateKey()
+
+	data, err := sk.Encrypt([]byte("password"), 32768, 16<<20)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	restored, err := ParsePrivateKey(data, []byte("password"))
+	if err != nil || !bytes.Equal(restored.key, sk.key) || restored.ID != sk.ID {
+		t.Fatalf("Encrypted secret key does not round trip: %v", err)
+	}
+
+	// Limits above the defaults are rejected before deriving the key
+	stream := make([]byte, KeyIDBytes+SecretKeyBytes+checksumBytes)
+	for _, limits := range [][2]uint64{{math.MaxUint64, DefaultMemLimit}, {DefaultOpsLimit, math.MaxUint64}} {
+		data = sk.marshal(kdfScrypt, make([]byte, saltBytes), limits[0], limits[1], stream)
+		if _, err := ParsePrivateKey(data, []byte("password")); err == nil {
+			t.Fatalf("Scrypt limits %v accepted", limits)
+		}
+	}
+
+	data, _ = sk.MarshalText()
+	if restored, err = ParsePrivateKey(data, nil); err != nil || !bytes.Equal(restored.key, sk.key) {
+		t.Fatalf("Unencrypted secret key does not round trip: %v", err)
+	}
+
+	other, err := ParsePublicKey(pk.String())
+	if err != nil || *other != *pk {
+		t.Fatalf("Public key does not round trip: %v", err)
+	}
+
+	s, _ := Sign(sk, strings.NewReader(""), "")
+	if !strings.HasPrefix(s.TrustedComment, "timestamp:") {
+		t.Fatalf("Unexpected default trusted comment %q", s.TrustedComment)
+	}
+
+	if err := Verify(pk, strings.NewReader(""), s); err != nil {
+		t.Fatal(err)
+	}
+
+	otherPK, _ := GenerateKey()
+	if err := Verify(otherPK, strings.NewReader(""), s); err == nil {
+		t.Fatal("Signature accepted with another key")
+	}
+
+	if _, err := Sign(sk, strings.NewReader(""), "line\nbreak"); err == nil {
+		t.Fatal("Multi-line trusted comment accepted")
+	}
+}
@@ -0,0 +1,181 @@
+package minisign
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"github.com/GoKillers/libsodium-go/cryptogenerichash"
+	"github.com/GoKillers/libsodium-go/cryptosign"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	trustedPrefix           = "trusted comment: "
+	defaultUntrustedComment = "signature from minisign secret key"
+	prehashBytes            = 64
+)
+
+// ErrInvalidSignature is returned by Verify when a signature does not verify.
+var ErrInvalidSignature = errors.New("minisign: invalid signature")
+
+// Signature is a minisign signature of a file.
+type Signature struct {
+	Algorithm        string // "ED" for prehashed signatures, "Ed" for legacy signatures
+	ID               KeyID
+	Signature        [SignatureBytes]byte
+	TrustedComment   string // Authenticated by the global signature
+	UntrustedComment string
+	GlobalSignature  [SignatureBytes]byte
+}
+
+// hashReader returns the BLAKE2b-512 hash of the contents of `r`.
+func hashReader(r io.Reader) ([]byte, error) {
+	state, _ := generichash.CryptoGenericHashInit(nil, prehashBytes)
+	buf := make([]byte, 32<<10)
+
+	for {
+		n, err := r.Read(buf)
+		generichash.CryptoGenericHashUpdate(state, buf[:n])
+
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	_, h, _ := generichash.CryptoGenericHashFinal(state, prehashBytes)
+
+	return h, nil
+}
+
+// isComment reports whether s can be used as a comment, which is a single line.
+func isComment(s string) bool {
+	return !strings.ContainsAny(s, "\r\n")
+}
+
+// Sign signs the contents of `r` in prehashed mode, with a trusted comment.
+// If the trusted comment is empty, the current time is used, as "timestamp:<unix time>".
+func Sign(sk *PrivateKey, r io.Reader, trustedComment string) (*Signature, error) {
+	if trustedComment == "" {
+		trustedComment = "timestamp:" + strconv.FormatInt(time.Now().Unix(), 10)
+	}
+	if !isComment(trustedComment) {
+		return nil, errors.New("minisign: comments must be a single line")
+	}
+
+	h, err := hashReader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Signature{
+		Algorithm:        algPrehashed,
+		ID:               sk.ID,
+		TrustedComment:   trustedComment,
+		UntrustedComment: defaultUntrustedComment,
+	}
+
+	sig, _ := cryptosign.CryptoSignDetached(h, sk.key)
+	copy(s.Signature[:], sig)
+
+	global, _ := cryptosign.CryptoSignDetached(s.globalMessage(), sk.key)
+	copy(s.GlobalSignature[:], global)
+
+	return s, nil
+}
+
+// globalMessage returns the message of the global signature: the signature followed by the trusted comment.
+func (s *Signature) globalMessage() []byte {
+	return append(append([]byte(nil), s.Signature[:]...), s.TrustedComment...)
+}
+
+// Verify verifies a signature of the contents of `r` with a public key, including
+// the global signature of its trusted comment. Only when it returns nil can the
+// trusted comment be relied on.
+func Verify(pk *PublicKey, r io.Reader, s *Signature) error {
+	if s.ID != pk.ID {
+		return errors.New("minisign: signature made with key " + s.ID.String() + ", not " + pk.ID.String())
+	}
+
+	var m []byte
+	var err error
+	switch s.Algorithm {
+	case algPrehashed:
+		m, err = hashReader(r)
+	case algEd25519:
+		m, err = io.ReadAll(r)
+	default:
+		return errors.New("minisign: unsupported signature algorithm")
+	}
+	if err != nil {
+		return err
+	}
+
+	if cryptosign.CryptoSignVerifyDetached(s.Signature[:], m, pk.Key[:]) != 0 {
+		return ErrInvalidSignature
+	}
+
+	if cryptosign.CryptoSignVerifyDetached(s.GlobalSignature[:], s.globalMessage(), pk.Key[:]) != 0 {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
+
+// ParseSignature parses the contents of a signature file.
+func ParseSignature(data []byte) (*Signature, error) {
+	invalid := errors.New("minisign: invalid signature file")
+
+	lines := strings.Split(strings.TrimRight(string(data), "\r\n"), "\n")
+	if len(lines) != 4 {
+		return nil, invalid
+	}
+	for i := range lines {
+		lines[i] = strings.TrimSuffix(lines[i], "\r")
+	}
+
+	if !strings.HasPrefix(lines[0], untrustedPrefix) || !strings.HasPrefix(lines[2], trustedPrefix) {
+		return nil, invalid
+	}
+
+	b, err := base64.StdEncoding.DecodeString(lines[1])
+	if err != nil || len(b) != 2+KeyIDBytes+SignatureBytes {
+		return nil, invalid
+	}
+
+	global, err := base64.StdEncoding.DecodeString(lines[3])
+	if err != nil || len(global) != SignatureBytes {
+		return nil, invalid
+	}
+
+	s := &Signature{
+		Algorithm:        string(b[:2]),
+		TrustedComment:   lines[2][len(trustedPrefix):],
+		UntrustedComment: lines[0][len(untrustedPrefix):],
+	}
+	copy(s.ID[:], b[2:])
+	copy(s.Signature[:], b[2+KeyIDBytes:])
+	copy(s.GlobalSignature[:], global)
+
+	return s, nil
+}
+
+// MarshalText returns the contents of a signature file.
+func (s *Signature) MarshalText() ([]byte, error) {
+	if !isComment(s.TrustedComment) || !isComment(s.UntrustedComment) {
+		return nil, errors.New("minisign: comments must be a single line")
+	}
+
+	var b bytes.Buffer
+	b.WriteString(untrustedPrefix + s.UntrustedComment + "\n")
+	b.WriteString(base64.StdEncoding.EncodeToString(append(append([]byte(s.Algorithm), s.ID[:]...), s.Signature[:]...)) + "\n")
+	b.WriteString(trustedPrefix + s.TrustedComment + "\n")
+	b.WriteString(base64.StdEncoding.EncodeToString(s.GlobalSignature[:]) + "\n")
+
+	return b.Bytes(), nil
+}
@@ -0,0 +1,205 @@
+package noise
+
+import (
+	"encoding/binary"
+	"errors"
+	"github.com/GoKillers/libsodium-go/crypto/aead/aes256gcm"
+	"github.com/GoKillers/libsodium-go/crypto/aead/chacha20poly1305ietf"
+	"github.com/GoKillers/libsodium-go/sodium"
+	"github.com/GoKillers/libsodium-go/support"
+	"math"
+)
+
+// keyLen is the size of a cipher key in bytes, and tagLen the size of an authentication tag.
+const (
+	keyLen = 32
+	tagLen = 16
+)
+
+// CipherState encrypts and decrypts messages with a key and a counter nonce.
+// Before a key has been set during a handshake, it passes data through unchanged.
+type CipherState struct {
+	cipher Cipher
+	k      *[keyLen]byte
+	n      uint64
+}
+
+// nonce returns the AEAD nonce for a counter: 32 bits of zeros followed by the counter,
+// little-endian for ChaCha20-Poly1305 and big-endian for AES256-GCM.
+func (c *CipherState) nonce(n uint64) *[chacha20poly1305ietf.NonceBytes]byte {
+	nonce := new([chacha20poly1305ietf.NonceBytes]byte)
+	if c.cipher == AESGCM {
+		binary.BigEndian.PutUint64(nonce[4:], n)
+	} else {
+		binary.LittleEndian.PutUint64(nonce[4:], n)
+	}
+
+	return nonce
+}
+
+func (c *CipherState) seal(out []byte, n uint64, ad, plaintext []byte) []byte {
+	if c.cipher == AESGCM {
+		return aes256gcm.Seal(out, plaintext, ad, (*[aes256gcm.NonceBytes]byte)(c.nonce(n)), (*[aes256gcm.KeyBytes]byte)(c.k))
+	}
+	return chacha20poly1305ietf.Seal(out, plaintext, ad, c.nonce(n), c.k)
+}
+
+func (c *CipherState) open(out []byte, n uint64, ad, ciphertext []byte) ([]byte, error) {
+	if c.cipher == AESGCM {
+		return aes256gcm.Open(out, ciphertext, ad, (*[aes256gcm.NonceBytes]byte)(c.nonce(n)), (*[aes256gcm.KeyBytes]byte)(c.k))
+	}
+	return chacha20poly1305ietf.Open(out, ciphertext, ad, c.nonce(n), c.k)
+}
+
+func (c *CipherState) initializeKey(k []byte) {
+	c.k = new([keyLen]byte)
+	copy(c.k[:], k)
+	c.n = 0
+}
+
+func (c *CipherState) hasKey() bool {
+	return c.k != nil
+}
+
+// Encrypt encrypts and authenticates a plaintext with additional data `ad`, appends the
+// ciphertext to `out` and increments the nonce. Without a key, the plaintext is appended.
+func (c *CipherState) Encrypt(out, ad, plaintext []byte) ([]byte, error) {
+	if !c.hasKey() {
+		return append(out, plaintext...), nil
+	}
+
+	if len(plaintext)+tagLen > MaxMessageLen {
+		return nil, errors.New("noise: message too long")
+	}
+
+	// The highest nonce is reserved for Rekey
+	if c.n == math.MaxUint64 {
+		return nil, &support.NonceExhaustedError{}
+	}
+
+	out = c.seal(out, c.n, ad, plaintext)
+	c.n++
+
+	return out, nil
+}
+
+// Decrypt verifies and decrypts a ciphertext with additional data `ad`, appends the
+// plaintext to `out` and increments the nonce. The nonce is not incremented if the
+// ciphertext does not verify. Without a key, the ciphertext is appended.
+func (c *CipherState) Decrypt(out, ad, ciphertext []byte) ([]byte, error) {
+	if !c.hasKey() {
+		return append(out, ciphertext...), nil
+	}
+
+	if len(ciphertext) < tagLen || len(ciphertext) > MaxMessageLen {
+		return nil, errors.New("noise: invalid message size")
+	}
+
+	if c.n == math.MaxUint64 {
+		return nil, &support.NonceExhaustedError{}
+	}
+
+	out, err := c.open(out, c.n, ad, ciphertext)
+	if err != nil {
+		return nil, err
+	}
+	c.n++
+
+	return out, nil
+}
+
+// Nonce returns the nonce of the next message.
+func (c *CipherState) Nonce() uint64 {
+	return c.n
+}
+
+// SetNonce sets the nonce of the next message, for transports that deliver
+// messages out of order. A nonce must never be used twice with the same key.
+func (c *CipherState) SetNonce(n uint64) {
+	c.n = n
+}
+
+// Rekey replaces the key with one derived from it, using the reserved highest nonce.
+func (c *CipherState) Rekey() {
+	if !c.hasKey() {
+		return
+	}
+
+	k := c.seal(nil, math.MaxUint64, nil, make([]byte, keyLen))
+	copy(c.k[:], k)
+	sodium.MemZero(k)
+}
+
+// symmetricState holds the chaining key and handshake hash of a handshake.
+type symmetricState struct {
+	cs   CipherState
+	hash Hash
+	ck   []byte
+	h    []byte
+}
+
+func (s *symmetricState) initialize(p Protocol) {
+	name := []byte(p.String())
+	if len(name) <= p.Hash.size() {
+		s.h = make([]byte, p.Hash.size())
+		copy(s.h, name)
+	} else {
+		s.h = p.Hash.sum(name)
+	}
+
+	s.ck = append([]byte(nil), s.h...)
+	s.hash = p.Hash
+	s.cs = CipherState{cipher: p.Cipher}
+}
+
+func (s *symmetricState) mixKey(ikm []byte) {
+	out := s.hash.hkdf(s.ck, ikm, 2)
+	s.ck = out[0]
+	s.cs.initializeKey(out[1][:keyLen])
+}
+
+func (s *symmetricState) mixHash(data []byte) {
+	s.h = s.hash.sum(s.h, data)
+}
+
+func (s *symmetricState) mixKeyAndHash(ikm []byte) {
+	out := s.hash.hkdf(s.ck, ikm, 3)
+	s.ck = out[0]
+	s.mixHash(out[1])
+	s.cs.initializeKey(out[2][:keyLen])
+}
+
+func (s *symmetricState) encryptAndHash(out, plaintext []byte) ([]byte, error) {
+	n := len(out)
+
+	out, err := s.cs.Encrypt(out, s.h, plaintext)
+	if err != nil {
+		return nil, err
+	}
+	s.mixHash(out[n:])
+
+	return out, nil
+}
+
+func (s *symmetricState) decryptAndHash(out, ciphertext []byte) ([]byte, error) {
+	out, err := s.cs.Decrypt(out, s.h, ciphertext)
+	if err != nil {
+		return nil, err
+	}
+	s.mixHash(ciphertext)
+
+	return out, nil
+}
+
+// split returns the cipher states for messages from the initiator and from the responder.
+func (s *symmetricState) split() (*CipherState, *CipherState) {
+	out := s.hash.hkdf(s.ck, nil, 2)
+
+	c1 := &CipherState{cipher: s.cs.cipher}
+	c1.initializeKey(out[0][:keyLen])
+
+	c2 := &CipherState{cipher: s.cs.cipher}
+	c2.initializeKey(out[1][:keyLen])
+
+	return c1, c2
+}
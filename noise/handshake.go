@@ -0,0 +1,349 @@
+package noise
+
+import (
+	"errors"
+)
+
+// PSKLen is the size of a pre-shared key in bytes.
+const PSKLen = 32
+
+// Config configures a HandshakeState.
+type Config struct {
+	Protocol  Protocol
+	Initiator bool
+	Prologue  []byte // Data both parties must agree on, such as a negotiation transcript
+
+	// StaticKeypair is the local static key pair, required by patterns that send or
+	// pre-share it. PeerStatic is the remote static public key, required by patterns
+	// where it is known before the handshake.
+	StaticKeypair *DHKey
+	PeerStatic    []byte
+
+	// PresharedKeys are used in the order of the psk tokens of the pattern.
+	PresharedKeys [][]byte
+
+	// EphemeralKeypair is generated during the handshake if nil. It should only be set for testing.
+	EphemeralKeypair *DHKey
+}
+
+// HandshakeState runs a handshake. It is not safe for concurrent use.
+type HandshakeState struct {
+	ss        symmetricState
+	pattern   pattern
+	initiator bool
+	s, e      *DHKey
+	rs, re    []byte
+	psks      [][]byte
+	message   int   // Index of the next message pattern
+	err       error // Set once the handshake has failed
+}
+
+// NewHandshakeState starts a handshake.
+func NewHandshakeState(c *Config) (*HandshakeState, error) {
+	if err := c.Protocol.check(); err != nil {
+		return nil, err
+	}
+
+	p, _ := parsePattern(c.Protocol.Pattern)
+
+	hs := &HandshakeState{
+		pattern:   p,
+		initiator: c.Initiator,
+		s:         c.StaticKeypair,
+		e:         c.EphemeralKeypair,
+		rs:        c.PeerStatic,
+	}
+
+	if len(c.PresharedKeys) != p.pskCount() {
+		return nil, errors.New("noise: wrong number of pre-shared keys")
+	}
+	for _, k := range c.PresharedKeys {
+		if len(k) != PSKLen {
+			return nil, errors.New("noise: invalid pre-shared key")
+		}
+	}
+	hs.psks = c.PresharedKeys
+
+	if hs.rs != nil && len(hs.rs) != DHLen {
+		return nil, errors.New("noise: invalid peer static key")
+	}
+
+	hs.ss.initialize(c.Protocol)
+	hs.ss.mixHash(c.Prologue)
+
+	local, remote := p.initiatorPre, p.responderPre
+	if !c.Initiator {
+		local, remote = remote, local
+	}
+
+	// Pre-messages are hashed in order: the initiator's first
+	pre := func(tokens []token, local bool) error {
+		for _, t := range tokens {
+			var k []byte
+			switch {
+			case t == tokenS && local && hs.s != nil:
+				k = hs.s.Public
+			case t == tokenS && !local:
+				k = hs.rs
+			case t == tokenE && local && hs.e != nil:
+				k = hs.e.Public
+			case t == tokenE && !local:
+				k = hs.re
+			}
+
+			if k == nil {
+				return errors.New("noise: missing pre-message key")
+			}
+			hs.ss.mixHash(k)
+		}
+		return nil
+	}
+
+	if c.Initiator {
+		if err := pre(local, true); err != nil {
+			return nil, err
+		}
+		if err := pre(remote, false); err != nil {
+			return nil, err
+		}
+	} else {
+		if err := pre(remote, false); err != nil {
+			return nil, err
+		}
+		if err := pre(local, true); err != nil {
+			return nil, err
+		}
+	}
+
+	if hs.s == nil && hs.needsStatic() {
+		return nil, errors.New("noise: the pattern requires a static key pair")
+	}
+
+	return hs, nil
+}
+
+// needsStatic reports whether the local party sends a static key or uses it in a DH.
+func (hs *HandshakeState) needsStatic() bool {
+	for i, m := range hs.pattern.messages {
+		fromInitiator := i%2 == 0
+		for _, t := range m {
+			switch t {
+			case tokenS:
+				if fromInitiator == hs.initiator {
+					return true
+				}
+			case tokenSS:
+				return true
+			case tokenES:
+				if !hs.initiator {
+					return true
+				}
+			case tokenSE:
+				if hs.initiator {
+					return true
+				}
+			}
+		}
+	}
+
+	return false
+}
+
+// HandshakeHash returns the handshake hash, which identifies the session once
+// the handshake is complete, for instance for channel binding.
+func (hs *HandshakeState) HandshakeHash() []byte {
+	return append([]byte(nil), hs.ss.h...)
+}
+
+// PeerStatic returns the static public key of the peer, or nil if it is not known yet.
+func (hs *HandshakeState) PeerStatic() []byte {
+	return hs.rs
+}
+
+// Complete reports whether all handshake messages have been processed.
+func (hs *HandshakeState) Complete() bool {
+	return hs.message == len(hs.pattern.messages)
+}
+
+// turn checks that the handshake is in progress and that it is the turn of the
+// local party to write, or of the peer if `write` is false.
+func (hs *HandshakeState) turn(write bool) error {
+	if hs.err != nil {
+		return hs.err
+	}
+
+	if hs.Complete() {
+		return errors.New("noise: handshake already complete")
+	}
+
+	if (hs.message%2 == 0) == hs.initiator != write {
+		return errors.New("noise: out of turn handshake message")
+	}
+
+	return nil
+}
+
+// mixDH mixes the result of a DH token into the chaining key.
+func (hs *HandshakeState) mixDH(t token) error {
+	var local *DHKey
+	var remote []byte
+
+	// es is the initiator's ephemeral with the responder's static, and se the other way around
+	switch {
+	case t == tokenEE:
+		local, remote = hs.e, hs.re
+	case t == tokenSS:
+		local, remote = hs.s, hs.rs
+	case (t == tokenES) == hs.initiator:
+		local, remote = hs.e, hs.rs
+	default:
+		local, remote = hs.s, hs.re
+	}
+
+	if local == nil || remote == nil {
+		return errors.New("noise: missing key for DH")
+	}
+
+	secret, err := dh(*local, remote)
+	if err != nil {
+		return err
+	}
+	hs.ss.mixKey(secret)
+
+	return nil
+}
+
+// finish advances to the next message pattern, and splits after the last one.
+func (hs *HandshakeState) finish() (*CipherState, *CipherState) {
+	hs.message++
+	if !hs.Complete() {
+		return nil, nil
+	}
+
+	return hs.ss.split()
+}
+
+// WriteMessage appends the next handshake message with a payload to `out`. After
+// the last message it also returns the cipher states for transport messages from
+// the initiator and from the responder, in that order; otherwise they are nil.
+func (hs *HandshakeState) WriteMessage(out, payload []byte) ([]byte, *CipherState, *CipherState, error) {
+	if err := hs.turn(true); err != nil {
+		return nil, nil, nil, err
+	}
+
+	n := len(out)
+	out, err := hs.writeMessage(out, payload)
+	if err != nil {
+		hs.err = err
+		return nil, nil, nil, err
+	}
+
+	if len(out)-n > MaxMessageLen {
+		hs.err = errors.New("noise: message too long")
+		return nil, nil, nil, hs.err
+	}
+
+	c1, c2 := hs.finish()
+
+	return out, c1, c2, nil
+}
+
+func (hs *HandshakeState) writeMessage(out, payload []byte) ([]byte, error) {
+	var err error
+
+	for _, t := range hs.pattern.messages[hs.message] {
+		switch t {
+		case tokenE:
+			if hs.e == nil {
+				k := GenerateKeypair()
+				hs.e = &k
+			}
+			out = append(out, hs.e.Public...)
+			hs.ss.mixHash(hs.e.Public)
+			if hs.pattern.psk {
+				hs.ss.mixKey(hs.e.Public)
+			}
+		case tokenS:
+			out, err = hs.ss.encryptAndHash(out, hs.s.Public)
+		case tokenPSK:
+			hs.ss.mixKeyAndHash(hs.psks[0])
+			hs.psks = hs.psks[1:]
+		default:
+			err = hs.mixDH(t)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return hs.ss.encryptAndHash(out, payload)
+}
+
+// ReadMessage processes the next handshake message from the peer, and appends its
+// payload to `out`. After the last message it also returns the cipher states for
+// transport messages from the initiator and from the responder, in that order;
+// otherwise they are nil. The handshake can not continue after an error.
+func (hs *HandshakeState) ReadMessage(out, message []byte) ([]byte, *CipherState, *CipherState, error) {
+	if err := hs.turn(false); err != nil {
+		return nil, nil, nil, err
+	}
+
+	if len(message) > MaxMessageLen {
+		return nil, nil, nil, errors.New("noise: message too long")
+	}
+
+	out, err := hs.readMessage(out, message)
+	if err != nil {
+		hs.err = err
+		return nil, nil, nil, err
+	}
+
+	c1, c2 := hs.finish()
+
+	return out, c1, c2, nil
+}
+
+func (hs *HandshakeState) readMessage(out, message []byte) ([]byte, error) {
+	short := errors.New("noise: message too short")
+
+	for _, t := range hs.pattern.messages[hs.message] {
+		switch t {
+		case tokenE:
+			if len(message) < DHLen {
+				return nil, short
+			}
+			hs.re = append([]byte(nil), message[:DHLen]...)
+			message = message[DHLen:]
+
+			hs.ss.mixHash(hs.re)
+			if hs.pattern.psk {
+				hs.ss.mixKey(hs.re)
+			}
+		case tokenS:
+			n := DHLen
+			if hs.ss.cs.hasKey() {
+				n += tagLen
+			}
+			if len(message) < n {
+				return nil, short
+			}
+
+			rs, err := hs.ss.decryptAndHash(nil, message[:n])
+			if err != nil {
+				return nil, err
+			}
+			hs.rs = rs
+			message = message[n:]
+		case tokenPSK:
+			hs.ss.mixKeyAndHash(hs.psks[0])
+			hs.psks = hs.psks[1:]
+		default:
+			if err := hs.mixDH(t); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return hs.ss.decryptAndHash(out, message)
+}
@@ -0,0 +1,80 @@
+package noise
+
+// #cgo pkg-config: libsodium
+// #include <stdlib.h>
+// #include <sodium.h>
+import "C"
+import (
+	"github.com/GoKillers/libsodium-go/cryptogenerichash"
+	"github.com/GoKillers/libsodium-go/support"
+)
+
+// size returns HASHLEN, the size of a hash in bytes.
+func (h Hash) size() int {
+	if h == BLAKE2b {
+		return 64
+	}
+	return 32
+}
+
+// blockSize returns BLOCKLEN, the block size used by HMAC.
+func (h Hash) blockSize() int {
+	if h == BLAKE2b {
+		return 128
+	}
+	return 64
+}
+
+// sum returns the hash of the concatenation of its arguments.
+func (h Hash) sum(data ...[]byte) []byte {
+	var in []byte
+	for _, d := range data {
+		in = append(in, d...)
+	}
+
+	if h == BLAKE2b {
+		out, _ := generichash.CryptoGenericHash(64, in, nil)
+		return out
+	}
+
+	out := make([]byte, C.crypto_hash_sha256_BYTES)
+	C.crypto_hash_sha256(
+		(*C.uchar)(&out[0]),
+		(*C.uchar)(support.BytePointer(in)),
+		(C.ulonglong)(len(in)))
+
+	return out
+}
+
+// hmac returns HMAC-HASH(key, data), which the Noise specification requires even
+// for BLAKE2b instead of its keyed mode.
+func (h Hash) hmac(key []byte, data ...[]byte) []byte {
+	k := make([]byte, h.blockSize())
+	if len(key) > len(k) {
+		key = h.sum(key)
+	}
+	copy(k, key)
+
+	ipad := make([]byte, len(k))
+	opad := make([]byte, len(k))
+	for i := range k {
+		ipad[i] = k[i] ^ 0x36
+		opad[i] = k[i] ^ 0x5c
+	}
+
+	return h.sum(opad, h.sum(append([][]byte{ipad}, data...)...))
+}
+
+// hkdf returns `n` outputs of HASHLEN bytes derived from a chaining key and input key material.
+func (h Hash) hkdf(ck, ikm []byte, n int) [][]byte {
+	temp := h.hmac(ck, ikm)
+
+	out := make([][]byte, n)
+	prev := []byte(nil)
+	for i := range out {
+		prev = h.hmac(temp, prev, []byte{byte(i + 1)})
+		out[i] = prev
+	}
+
+	return out
+}
@@ -0,0 +1,137 @@
+// Package noise implements the Noise Protocol Framework (revision 34) for
+// mutually authenticated key exchange and transport encryption.
+//
+// A handshake follows one of the patterns N, NK, KK, IK or XX, optionally with
+// pre-shared key modifiers such as "XXpsk3", using Curve25519 for key agreement,
+// ChaCha20-Poly1305 or AES256-GCM for encryption, and SHA-256 or BLAKE2b for
+// hashing. A HandshakeState processes the handshake messages in turn with
+// WriteMessage and ReadMessage; after the last message both parties obtain a
+// pair of CipherStates for transport messages.
+package noise
+
+import (
+	"errors"
+	"github.com/GoKillers/libsodium-go/crypto/aead/aes256gcm"
+	"github.com/GoKillers/libsodium-go/randombytes"
+	"github.com/GoKillers/libsodium-go/scalarmult"
+	"strings"
+)
+
+// MaxMessageLen is the maximum size of a Noise message in bytes.
+const MaxMessageLen = 65535
+
+// DHLen is the size of a Curve25519 public key and shared secret in bytes.
+const DHLen = 32
+
+// Cipher identifies the AEAD of a protocol.
+type Cipher byte
+
+// Supported ciphers.
+const (
+	ChaChaPoly Cipher = iota + 1 // ChaCha20-Poly1305-IETF
+	AESGCM                       // AES256-GCM, requires hardware support
+)
+
+// Hash identifies the hash function of a protocol.
+type Hash byte
+
+// Supported hash functions.
+const (
+	SHA256 Hash = iota + 1
+	BLAKE2b
+)
+
+// Protocol is a combination of a handshake pattern and cryptographic functions.
+type Protocol struct {
+	Pattern string // Handshake pattern with optional modifiers, such as "IK" or "XXpsk3"
+	Cipher  Cipher
+	Hash    Hash
+}
+
+var (
+	cipherNames = map[Cipher]string{ChaChaPoly: "ChaChaPoly", AESGCM: "AESGCM"}
+	hashNames   = map[Hash]string{SHA256: "SHA256", BLAKE2b: "BLAKE2b"}
+)
+
+// String returns the Noise protocol name, such as "Noise_XX_25519_ChaChaPoly_BLAKE2b".
+func (p Protocol) String() string {
+	return "Noise_" + p.Pattern + "_25519_" + cipherNames[p.Cipher] + "_" + hashNames[p.Hash]
+}
+
+// ParseProtocolName parses a Noise protocol name. It returns an error for
+// patterns and functions that are not supported.
+func ParseProtocolName(name string) (Protocol, error) {
+	parts := strings.Split(name, "_")
+	if len(parts) != 5 || parts[0] != "Noise" || parts[2] != "25519" {
+		return Protocol{}, errors.New("noise: unsupported protocol " + name)
+	}
+
+	p := Protocol{Pattern: parts[1]}
+	for c, n := range cipherNames {
+		if n == parts[3] {
+			p.Cipher = c
+		}
+	}
+	for h, n := range hashNames {
+		if n == parts[4] {
+			p.Hash = h
+		}
+	}
+
+	if err := p.check(); err != nil {
+		return Protocol{}, err
+	}
+
+	return p, nil
+}
+
+// check returns an error if the protocol is not supported.
+func (p Protocol) check() error {
+	if _, ok := cipherNames[p.Cipher]; !ok {
+		return errors.New("noise: unsupported cipher")
+	}
+
+	if p.Cipher == AESGCM && !aes256gcm.IsAvailable() {
+		return errors.New("noise: AES256-GCM is not available on this CPU")
+	}
+
+	if _, ok := hashNames[p.Hash]; !ok {
+		return errors.New("noise: unsupported hash function")
+	}
+
+	_, err := parsePattern(p.Pattern)
+
+	return err
+}
+
+// DHKey is a Curve25519 key pair.
+type DHKey struct {
+	Private []byte
+	Public  []byte
+}
+
+// GenerateKeypair generates a random Curve25519 key pair.
+func GenerateKeypair() DHKey {
+	return NewKeypair(randombytes.RandomBytes(DHLen))
+}
+
+// NewKeypair returns the key pair of a private key.
+func NewKeypair(private []byte) DHKey {
+	public, _ := scalarmult.CryptoScalarmultBase(private)
+	return DHKey{Private: private, Public: public}
+}
+
+// dh returns the shared secret of a key pair and a public key. It returns an
+// error if the public key is of low order, which would make the secret all zeros.
+func dh(k DHKey, public []byte) ([]byte, error) {
+	if len(public) != DHLen {
+		return nil, errors.New("noise: invalid public key")
+	}
+
+	secret, exit := scalarmult.CryptoScalarMult(k.Private, public)
+	if exit != 0 {
+		return nil, errors.New("noise: invalid public key")
+	}
+
+	return secret, nil
+}
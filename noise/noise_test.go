@@ -0,0 +1,260 @@
+package noise
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+)
+
+// vectorKeys records which static keys a pattern uses and which are known
+// to the peer before the handshake.
+type vectorKeys struct {
+	initStatic, respStatic, initKnown, respKnown bool
+}
+
+var patternKeys = map[string]vectorKeys{
+	"N":  {respStatic: true, respKnown: true},
+	"NK": {respStatic: true, respKnown: true},
+	"KK": {initStatic: true, initKnown: true, respStatic: true, respKnown: true},
+	"IK": {initStatic: true, respStatic: true, respKnown: true},
+	"XX": {initStatic: true, respStatic: true},
+}
+
+// readVectors reads test vectors in the format of github.com/flynn/noise: blocks
+// of key=value lines, separated by empty lines.
+func readVectors(name string) ([]map[string]string, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	var vectors []map[string]string
+	for _, block := range strings.Split(strings.TrimSpace(string(data)), "\n\n") {
+		v := make(map[string]string)
+		for _, line := range strings.Split(block, "\n") {
+			key, value, _ := strings.Cut(line, "=")
+			v[key] = value
+		}
+		vectors = append(vectors, v)
+	}
+
+	return vectors, nil
+}
+
+func hexBytes(s string) []byte {
+	b, _ := hex.DecodeString(s)
+	return b
+}
+
+// keypair returns the key pair of a hex-encoded private key, or nil if it is empty.
+func keypair(private string) *DHKey {
+	if private == "" {
+		return nil
+	}
+
+	k := NewKeypair(hexBytes(private))
+	return &k
+}
+
+// TestVectors checks the vectors of github.com/flynn/noise v1.1.0 for the
+// supported protocols; see testdata/README.
+func TestVectors(t *testing.T) {
+	vectors, err := readVectors("testdata/vectors.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	run := 0
+	for _, v := range vectors {
+		name := v["handshake"]
+		p, err := ParseProtocolName(name)
+		if err != nil {
+			// AES-GCM without hardware support
+			continue
+		}
+		run++
+
+		keys := patternKeys[strings.SplitN(p.Pattern, "psk", 2)[0]]
+		is, rs := keypair(v["init_static"]), keypair(v["resp_static"])
+
+		ic := &Config{Protocol: p, Initiator: true, Prologue: hexBytes(v["prologue"]), EphemeralKeypair: keypair(v["gen_init_ephemeral"])}
+		rc := &Config{Protocol: p, Prologue: hexBytes(v["prologue"]), EphemeralKeypair: keypair(v["gen_resp_ephemeral"])}
+		if keys.initStatic {
+			ic.StaticKeypair = is
+		}
+		if keys.initKnown {
+			rc.PeerStatic = is.Public
+		}
+		if keys.respStatic {
+			rc.StaticKeypair = rs
+		}
+		if keys.respKnown {
+			ic.PeerStatic = rs.Public
+		}
+		if psk, ok := v["preshared_key"]; ok {
+			ic.PresharedKeys = [][]byte{hexBytes(psk)}
+			rc.PresharedKeys = ic.PresharedKeys
+		}
+
+		initiator, err := NewHandshakeState(ic)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		responder, err := NewHandshakeState(rc)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+
+		var c1, c2 *CipherState
+		handshake := len(initiator.pattern.messages)
+
+		for i := 0; ; i++ {
+			payload, ok := v[fmt.Sprintf("msg_%d_payload", i)]
+			if !ok {
+				break
+			}
+			want := hexBytes(v[fmt.Sprintf("msg_%d_ciphertext", i)])
+
+			var c, m []byte
+			if i < handshake {
+				w, r := initiator, responder
+				if i%2 != 0 {
+					w, r = r, w
+				}
+
+				c, c1, c2, err = w.WriteMessage(nil, hexBytes(payload))
+				if err != nil {
+					t.Fatalf("%s: message %d: %v", name, i, err)
+				}
+
+				if m, _, _, err = r.ReadMessage(nil, c); err != nil {
+					t.Fatalf("%s: message %d: %v", name, i, err)
+				}
+			} else {
+				// Transport messages alternate between the cipher states
+				cs := c1
+				if (i-handshake)%2 != 0 {
+					cs = c2
+				}
+
+				// Both parties share the cipher states in this test, so the
+				// nonce is rewound for decryption
+				n := cs.Nonce()
+				if c, err = cs.Encrypt(nil, nil, hexBytes(payload)); err != nil {
+					t.Fatal(err)
+				}
+				cs.SetNonce(n)
+				if m, err = cs.Decrypt(nil, nil, c); err != nil {
+					t.Fatalf("%s: message %d: %v", name, i, err)
+				}
+			}
+
+			if !bytes.Equal(c, want) {
+				t.Fatalf("%s: message %d: wrong ciphertext", name, i)
+			}
+			if !bytes.Equal(m, hexBytes(payload)) {
+				t.Fatalf("%s: message %d: wrong payload", name, i)
+			}
+		}
+	}
+
+	if run == 0 {
+		t.Fatal("No test vectors were run")
+	}
+}
+
+func TestHandshake(t *testing.T) {
+	p, _ := ParseProtocolName("Noise_IKpsk2_25519_ChaChaPoly_BLAKE2b")
+	is, rs := GenerateKeypair(), GenerateKeypair()
+	psk := bytes.Repeat([]byte{7}, PSKLen)
+
+	initiator, _ := NewHandshakeState(&Config{Protocol: p, Initiator: true, StaticKeypair: &is, PeerStatic: rs.Public, PresharedKeys: [][]byte{psk}})
+	responder, _ := NewHandshakeState(&Config{Protocol: p, StaticKeypair: &rs, PresharedKeys: [][]byte{psk}})
+
+	if _, _, _, err := responder.WriteMessage(nil, nil); err == nil {
+		t.Fatal("Responder wrote out of turn")
+	}
+
+	m1, _, _, _ := initiator.WriteMessage(nil, []byte("hello"))
+	if p, _, _, err := responder.ReadMessage(nil, m1); err != nil || string(p) != "hello" {
+		t.Fatalf("Reading the first message failed: %v", err)
+	}
+
+	if !bytes.Equal(responder.PeerStatic(), is.Public) {
+		t.Fatal("Responder did not learn the initiator's static key")
+	}
+
+	m2, rc1, rc2, _ := responder.WriteMessage(nil, nil)
+	_, ic1, ic2, err := initiator.ReadMessage(nil, m2)
+	if err != nil || ic1 == nil || rc2 == nil || !initiator.Complete() {
+		t.Fatalf("Handshake failed: %v", err)
+	}
+
+	c, _ := ic1.Encrypt(nil, nil, []byte("ping"))
+	if p, err := rc1.Decrypt(nil, nil, c); err != nil || string(p) != "ping" {
+		t.Fatalf("Transport message failed: %v", err)
+	}
+
+	// A replayed message fails, and the nonce of the receiver is unchanged
+	if _, err := rc1.Decrypt(nil, nil, c); err == nil || rc1.Nonce() != 1 {
+		t.Fatal("Replayed message accepted")
+	}
+
+	ic2.Rekey()
+	rc2.Rekey()
+	c, _ = rc2.Encrypt(nil, nil, []byte("pong"))
+	if p, err := ic2.Decrypt(nil, nil, c); err != nil || string(p) != "pong" {
+		t.Fatalf("Transport message after rekey failed: %v", err)
+	}
+}
+
+func TestHandshakeFailure(t *testing.T) {
+	p, _ := ParseProtocolName("Noise_XXpsk3_25519_ChaChaPoly_SHA256")
+	is, rs := GenerateKeypair(), GenerateKeypair()
+
+	initiator, _ := NewHandshakeState(&Config{Protocol: p, Initiator: true, StaticKeypair: &is, PresharedKeys: [][]byte{make([]byte, PSKLen)}})
+	responder, _ := NewHandshakeState(&Config{Protocol: p, StaticKeypair: &rs, PresharedKeys: [][]byte{bytes.Repeat([]byte{1}, PSKLen)}})
+
+	m, _, _, _ := initiator.WriteMessage(nil, nil)
+	responder.ReadMessage(nil, m)
+	m, _, _, _ = responder.WriteMessage(nil, nil)
+	initiator.ReadMessage(nil, m)
+	m, _, _, _ = initiator.WriteMessage(nil, nil)
+
+	if _, _, _, err := responder.ReadMessage(nil, m); err == nil {
+		t.Fatal("Handshake with different pre-shared keys succeeded")
+	}
+
+	if _, _, _, err := responder.WriteMessage(nil, nil); err == nil {
+		t.Fatal("Handshake continued after a failure")
+	}
+}
+
+func TestParseProtocolName(t *testing.T) {
+	for _, name := range []string{
+		"Noise_XX_25519_ChaChaPoly_BLAKE2b",
+		"Noise_NKpsk0_25519_ChaChaPoly_SHA256",
+		"Noise_XXpsk0+psk3_25519_ChaChaPoly_SHA256",
+	} {
+		p, err := ParseProtocolName(name)
+		if err != nil || p.String() != name {
+			t.Fatalf("%s: %v", name, err)
+		}
+	}
+
+	for _, name := range []string{
+		"Noise_XK_25519_ChaChaPoly_SHA256",
+		"Noise_XX_448_ChaChaPoly_SHA256",
+		"Noise_XX_25519_ChaChaPoly_SHA512",
+		"Noise_XXpsk4_25519_ChaChaPoly_SHA256",
+		"Noise_XXpsk+3_25519_ChaChaPoly_SHA256",
+		"Noise_XXfallback_25519_ChaChaPoly_SHA256",
+	} {
+		if _, err := ParseProtocolName(name); err == nil {
+			t.Fatalf("Unsupported protocol %s accepted", name)
+		}
+	}
+}
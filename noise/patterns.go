@@ -0,0 +1,113 @@
+package noise
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// token is a token of a message pattern.
+type token byte
+
+const (
+	tokenE token = iota + 1
+	tokenS
+	tokenEE
+	tokenES
+	tokenSE
+	tokenSS
+	tokenPSK
+)
+
+// pattern is a handshake pattern: the pre-messages of both parties, and the
+// message patterns, alternately sent by the initiator and the responder.
+type pattern struct {
+	initiatorPre []token
+	responderPre []token
+	messages     [][]token
+	psk          bool
+}
+
+// Supported base patterns, from section 7 of the specification.
+var patterns = map[string]pattern{
+	"N": {
+		responderPre: []token{tokenS},
+		messages:     [][]token{{tokenE, tokenES}},
+	},
+	"NK": {
+		responderPre: []token{tokenS},
+		messages:     [][]token{{tokenE, tokenES}, {tokenE, tokenEE}},
+	},
+	"KK": {
+		initiatorPre: []token{tokenS},
+		responderPre: []token{tokenS},
+		messages:     [][]token{{tokenE, tokenES, tokenSS}, {tokenE, tokenEE, tokenSE}},
+	},
+	"IK": {
+		responderPre: []token{tokenS},
+		messages:     [][]token{{tokenE, tokenES, tokenS, tokenSS}, {tokenE, tokenEE, tokenSE}},
+	},
+	"XX": {
+		messages: [][]token{{tokenE}, {tokenE, tokenEE, tokenS, tokenES}, {tokenS, tokenSE}},
+	},
+}
+
+// parsePattern returns a base pattern with its modifiers applied. The only
+// supported modifiers are pskN, which insert a psk token at the start of the first
+// message for N = 0, and at the end of message N otherwise. Modifiers are
+// separated by "+", as in "XXpsk0+psk3".
+func parsePattern(name string) (pattern, error) {
+	i := 0
+	for i < len(name) && name[i] >= 'A' && name[i] <= 'Z' {
+		i++
+	}
+
+	base, ok := patterns[name[:i]]
+	if !ok {
+		return pattern{}, errors.New("noise: unsupported handshake pattern " + name)
+	}
+
+	p := pattern{
+		initiatorPre: base.initiatorPre,
+		responderPre: base.responderPre,
+		messages:     make([][]token, len(base.messages)),
+	}
+	for j, m := range base.messages {
+		p.messages[j] = append([]token(nil), m...)
+	}
+
+	if i == len(name) {
+		return p, nil
+	}
+
+	for _, modifier := range strings.Split(name[i:], "+") {
+		n, err := strconv.Atoi(strings.TrimPrefix(modifier, "psk"))
+		if !strings.HasPrefix(modifier, "psk") || err != nil || strconv.Itoa(n) != modifier[3:] ||
+			n < 0 || n > len(p.messages) {
+			return pattern{}, errors.New("noise: unsupported pattern modifier " + modifier)
+		}
+
+		if n == 0 {
+			p.messages[0] = append([]token{tokenPSK}, p.messages[0]...)
+		} else {
+			p.messages[n-1] = append(p.messages[n-1], tokenPSK)
+		}
+		p.psk = true
+	}
+
+	return p, nil
+}
+
+// pskCount returns the number of psk tokens in the pattern.
+func (p pattern) pskCount() int {
+	n := 0
+	for _, m := range p.messages {
+		for _, t := range m {
+			if t == tokenPSK {
+				n++
+			}
+		}
+	}
+
+	return n
+}
@@ -0,0 +1,228 @@
+// Package paseto implements version 4 of PASETO (Platform-Agnostic Security Tokens).
+//
+// A v4.local token is encrypted with XChaCha20 and authenticated with keyed
+// BLAKE2b under subkeys derived from a shared key and a random nonce. A v4.public
+// token carries its message in the clear, signed with Ed25519.
+//
+// Both purposes support an optional footer, which is authenticated but not
+// encrypted and is typically used for a key ID, and an optional implicit
+// assertion, which is authenticated but not included in the token.
+package paseto
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"github.com/GoKillers/libsodium-go/cryptogenerichash"
+	"github.com/GoKillers/libsodium-go/cryptosign"
+	"github.com/GoKillers/libsodium-go/cryptostream"
+	"github.com/GoKillers/libsodium-go/randombytes"
+	"github.com/GoKillers/libsodium-go/sodium"
+	"github.com/GoKillers/libsodium-go/support"
+	"strings"
+)
+
+// Sizes of keys and token components.
+const (
+	LocalKeyBytes  = 32 // Size of a v4.local key
+	PublicKeyBytes = 32 // Size of a v4.public (Ed25519) public key
+	SecretKeyBytes = 64 // Size of a v4.public (Ed25519) secret key
+	NonceBytes     = 32
+	TagBytes       = 32
+	SignatureBytes = 64
+)
+
+const (
+	localHeader  = "v4.local."
+	publicHeader = "v4.public."
+)
+
+// ErrInvalidToken is returned when a token is malformed or of another version or purpose.
+var ErrInvalidToken = errors.New("paseto: invalid token")
+
+var b64 = base64.RawURLEncoding.Strict()
+
+// PAE returns the pre-authentication encoding of a list of byte strings: the number of
+// strings and the length of each string before it, all as 64-bit little-endian integers.
+func PAE(pieces ...[]byte) []byte {
+	n := 8
+	for _, p := range pieces {
+		n += 8 + len(p)
+	}
+
+	b := make([]byte, 0, n)
+	b = binary.LittleEndian.AppendUint64(b, uint64(len(pieces)))
+	for _, p := range pieces {
+		b = binary.LittleEndian.AppendUint64(b, uint64(len(p)))
+		b = append(b, p...)
+	}
+
+	return b
+}
+
+// GenerateLocalKey generates a random v4.local key.
+func GenerateLocalKey() *[LocalKeyBytes]byte {
+	k := new([LocalKeyBytes]byte)
+	randombytes.RandomBytesBuf(k[:])
+	return k
+}
+
+// GenerateKeyPair generates a v4.public key pair.
+func GenerateKeyPair() (*[PublicKeyBytes]byte, *[SecretKeyBytes]byte) {
+	sk, pk, _ := cryptosign.CryptoSignKeyPair()
+	return (*[PublicKeyBytes]byte)(pk), (*[SecretKeyBytes]byte)(sk)
+}
+
+// encode returns a token from its header, payload and footer.
+func encode(header string, payload, footer []byte) string {
+	token := header + b64.EncodeToString(payload)
+	if len(footer) > 0 {
+		token += "." + b64.EncodeToString(footer)
+	}
+
+	return token
+}
+
+// decode returns the payload and footer of a token with a given header.
+func decode(header, token string) (payload, footer []byte, err error) {
+	if !strings.HasPrefix(token, header) {
+		return nil, nil, ErrInvalidToken
+	}
+
+	p, f, hasFooter := strings.Cut(token[len(header):], ".")
+	if hasFooter && f == "" {
+		return nil, nil, ErrInvalidToken
+	}
+
+	if payload, err = b64.DecodeString(p); err != nil {
+		return nil, nil, ErrInvalidToken
+	}
+
+	if footer, err = b64.DecodeString(f); err != nil {
+		return nil, nil, ErrInvalidToken
+	}
+
+	return payload, footer, nil
+}
+
+// Footer returns the footer of a token without verifying it, for instance to
+// look up the key from a key ID. The footer must not be trusted before the token
+// has been decrypted or verified.
+func Footer(token string) ([]byte, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) == 3 {
+		return nil, nil
+	}
+	if len(parts) != 4 || parts[3] == "" {
+		return nil, ErrInvalidToken
+	}
+
+	footer, err := b64.DecodeString(parts[3])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	return footer, nil
+}
+
+// blake2b returns a keyed BLAKE2b hash of `length` bytes.
+func blake2b(length int, key []byte, m ...[]byte) []byte {
+	var in []byte
+	for _, p := range m {
+		in = append(in, p...)
+	}
+
+	h, _ := generichash.CryptoGenericHash(length, in, key)
+
+	return h
+}
+
+// Encrypt returns a v4.local token of a message, with an optional footer and implicit assertion.
+func Encrypt(k *[LocalKeyBytes]byte, message, footer, implicit []byte) string {
+	support.NilPanic(k == nil, "key")
+	return encrypt(k, randombytes.RandomBytes(NonceBytes), message, footer, implicit)
+}
+
+func encrypt(k *[LocalKeyBytes]byte, nonce, message, footer, implicit []byte) string {
+	ek, n2, ak := localKeys(k, nonce)
+	defer sodium.MemZero(ek)
+	defer sodium.MemZero(ak)
+
+	payload := append(make([]byte, 0, NonceBytes+len(message)+TagBytes), nonce...)
+	payload = cryptostream.AppendXChaCha20XOR(payload, message, n2, ek)
+
+	tag := blake2b(TagBytes, ak, PAE([]byte(localHeader), nonce, payload[NonceBytes:], footer, implicit))
+
+	return encode(localHeader, append(payload, tag...), footer)
+}
+
+// localKeys derives the encryption key, the XChaCha20 nonce and the authentication key for a nonce.
+func localKeys(k *[LocalKeyBytes]byte, nonce []byte) (ek, n2, ak []byte) {
+	tmp := blake2b(56, k[:], []byte("paseto-encryption-key"), nonce)
+	ak = blake2b(32, k[:], []byte("paseto-auth-key-for-aead"), nonce)
+
+	return tmp[:32], tmp[32:], ak
+}
+
+// Decrypt verifies and decrypts a v4.local token with an implicit assertion, and returns
+// its message and footer. The caller should check that the footer is as expected.
+func Decrypt(k *[LocalKeyBytes]byte, token string, implicit []byte) (message, footer []byte, err error) {
+	support.NilPanic(k == nil, "key")
+
+	payload, footer, err := decode(localHeader, token)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(payload) < NonceBytes+TagBytes {
+		return nil, nil, ErrInvalidToken
+	}
+
+	nonce := payload[:NonceBytes]
+	c := payload[NonceBytes : len(payload)-TagBytes]
+	tag := payload[len(payload)-TagBytes:]
+
+	ek, n2, ak := localKeys(k, nonce)
+	defer sodium.MemZero(ek)
+	defer sodium.MemZero(ak)
+
+	expected := blake2b(TagBytes, ak, PAE([]byte(localHeader), nonce, c, footer, implicit))
+	if sodium.MemCmp(tag, expected, TagBytes) != 0 {
+		return nil, nil, &support.VerificationError{}
+	}
+
+	return cryptostream.AppendXChaCha20XOR(nil, c, n2, ek), footer, nil
+}
+
+// Sign returns a v4.public token of a message, with an optional footer and implicit assertion.
+func Sign(sk *[SecretKeyBytes]byte, message, footer, implicit []byte) string {
+	support.NilPanic(sk == nil, "secret key")
+
+	sig, _ := cryptosign.CryptoSignDetached(PAE([]byte(publicHeader), message, footer, implicit), sk[:])
+
+	return encode(publicHeader, append(append(make([]byte, 0, len(message)+SignatureBytes), message...), sig...), footer)
+}
+
+// Verify verifies a v4.public token with an implicit assertion, and returns its
+// message and footer. The caller should check that the footer is as expected.
+func Verify(pk *[PublicKeyBytes]byte, token string, implicit []byte) (message, footer []byte, err error) {
+	support.NilPanic(pk == nil, "public key")
+
+	payload, footer, err := decode(publicHeader, token)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(payload) < SignatureBytes {
+		return nil, nil, ErrInvalidToken
+	}
+
+	message = payload[:len(payload)-SignatureBytes]
+	sig := payload[len(payload)-SignatureBytes:]
+
+	if cryptosign.CryptoSignVerifyDetached(sig, PAE([]byte(publicHeader), message, footer, implicit), pk[:]) != 0 {
+		return nil, nil, &support.VerificationError{}
+	}
+
+	return message, footer, nil
+}
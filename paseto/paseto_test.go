@@ -0,0 +1,184 @@
+package paseto
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/GoKillers/libsodium-go/support"
+	"os"
+	"strings"
+	"testing"
+)
+
+var testPublicKey = (*[PublicKeyBytes]byte)(hexBytes("1eb9dbbbbc047c03fd70604e0071f0987e16b28b757225c11f00415d0e20b1a2"))
+
+func hexBytes(s string) []byte {
+	b, _ := hex.DecodeString(s)
+	return b
+}
+
+func isVerificationError(err error) bool {
+	_, ok := err.(*support.VerificationError)
+	return ok
+}
+
+// testVector is an official PASETO test vector from testdata/v4.json. Local
+// vectors have a key and a nonce, public vectors a key pair.
+type testVector struct {
+	Name       string `json:"name"`
+	ExpectFail bool   `json:"expect-fail"`
+	Key        string `json:"key"`
+	Nonce      string `json:"nonce"`
+	PublicKey  string `json:"public-key"`
+	SecretKey  string `json:"secret-key"`
+	Token      string `json:"token"`
+	Payload    string `json:"payload"`
+	Footer     string `json:"footer"`
+	Implicit   string `json:"implicit-assertion"`
+}
+
+func readVectors(t *testing.T) []testVector {
+	data, err := os.ReadFile("testdata/v4.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var file struct {
+		Tests []testVector `json:"tests"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		t.Fatal(err)
+	}
+
+	return file.Tests
+}
+
+func findVector(t *testing.T, name string) testVector {
+	for _, v := range readVectors(t) {
+		if v.Name == name {
+			return v
+		}
+	}
+
+	t.Fatalf("No test vector %s", name)
+	return testVector{}
+}
+
+func TestVectors(t *testing.T) {
+	vectors := readVectors(t)
+	if len(vectors) == 0 {
+		t.Fatal("No test vectors")
+	}
+
+	for _, v := range vectors {
+		t.Run(v.Name, func(t *testing.T) {
+			implicit := []byte(v.Implicit)
+
+			switch {
+			case v.ExpectFail && v.PublicKey != "":
+				pk := (*[PublicKeyBytes]byte)(hexBytes(v.PublicKey))
+				if _, _, err := Verify(pk, v.Token, implicit); err == nil {
+					t.Fatal("Invalid token accepted")
+				}
+			case v.ExpectFail:
+				k := (*[LocalKeyBytes]byte)(hexBytes(v.Key))
+				if _, _, err := Decrypt(k, v.Token, implicit); err == nil {
+					t.Fatal("Invalid token accepted")
+				}
+			case v.PublicKey != "":
+				sk := (*[SecretKeyBytes]byte)(hexBytes(v.SecretKey))
+				if token := Sign(sk, []byte(v.Payload), []byte(v.Footer), implicit); token != v.Token {
+					t.Fatalf("Wrong token %s", token)
+				}
+
+				pk := (*[PublicKeyBytes]byte)(hexBytes(v.PublicKey))
+				m, f, err := Verify(pk, v.Token, implicit)
+				if err != nil || string(m) != v.Payload || string(f) != v.Footer {
+					t.Fatalf("Verification failed: %v", err)
+				}
+
+				if _, _, err := Verify(pk, v.Token, []byte("other")); !isVerificationError(err) {
+					t.Fatalf("Wrong implicit assertion accepted: %v", err)
+				}
+			default:
+				k := (*[LocalKeyBytes]byte)(hexBytes(v.Key))
+				if token := encrypt(k, hexBytes(v.Nonce), []byte(v.Payload), []byte(v.Footer), implicit); token != v.Token {
+					t.Fatalf("Wrong token %s", token)
+				}
+
+				m, f, err := Decrypt(k, v.Token, implicit)
+				if err != nil || string(m) != v.Payload || string(f) != v.Footer {
+					t.Fatalf("Decryption failed: %v", err)
+				}
+
+				if _, _, err := Decrypt(k, v.Token, []byte("other")); !isVerificationError(err) {
+					t.Fatalf("Wrong implicit assertion accepted: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestInvalidTokens(t *testing.T) {
+	// Vectors with a footer and an implicit assertion
+	lv, pv := findVector(t, "4-E-7"), findVector(t, "4-S-3")
+	k := (*[LocalKeyBytes]byte)(hexBytes(lv.Key))
+	pk := (*[PublicKeyBytes]byte)(hexBytes(pv.PublicKey))
+	local, public := lv.Token, pv.Token
+
+	for _, token := range []string{
+		public,           // Wrong purpose
+		"v3" + local[2:], // Wrong version
+		local[:strings.LastIndexByte(local, '.')+1], // Empty footer after separator
+		local + "=",     // Padding
+		"v4.local.AAAA", // Too short
+	} {
+		if _, _, err := Decrypt(k, token, []byte(lv.Implicit)); err != ErrInvalidToken {
+			t.Fatalf("Invalid token %q: expected ErrInvalidToken, got %v", token, err)
+		}
+	}
+
+	if _, _, err := Verify(pk, local, nil); err != ErrInvalidToken {
+		t.Fatalf("Token with wrong purpose: expected ErrInvalidToken, got %v", err)
+	}
+
+	// Every modified character of the payload or footer is detected
+	for i := len(localHeader); i < len(local); i++ {
+		if local[i] == '.' {
+			continue
+		}
+
+		b := []byte(local)
+		b[i] ^= 1
+		if _, _, err := Decrypt(k, string(b), []byte(lv.Implicit)); err == nil {
+			t.Fatalf("Modified character %d accepted", i)
+		}
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	k := GenerateLocalKey()
+	m := []byte(`{"sub":"user"}`)
+
+	token := Encrypt(k, m, []byte("kid"), nil)
+	if f, _ := Footer(token); string(f) != "kid" {
+		t.Fatalf("Wrong footer %q", f)
+	}
+
+	if p, _, err := Decrypt(k, token, nil); err != nil || !bytes.Equal(p, m) {
+		t.Fatalf("Decryption failed: %v", err)
+	}
+
+	if Encrypt(k, m, nil, nil) == Encrypt(k, m, nil, nil) {
+		t.Fatal("Nonce reused")
+	}
+
+	pk, sk := GenerateKeyPair()
+	if p, _, err := Verify(pk, Sign(sk, m, nil, nil), nil); err != nil || !bytes.Equal(p, m) {
+		t.Fatalf("Verification failed: %v", err)
+	}
+
+	if _, _, err := Verify(testPublicKey, Sign(sk, m, nil, nil), nil); err == nil {
+		t.Fatal("Token accepted with another key")
+	}
+}
@@ -4,9 +4,15 @@ package randombytes
 // #include <stdlib.h>
 // #include <sodium.h>
 import "C"
+import "github.com/GoKillers/libsodium-go/sodium"
 import "github.com/GoKillers/libsodium-go/support"
 import "unsafe"
 
+// Sodium should always be initialised
+func init() {
+	sodium.Initialize()
+}
+
 // RandomBytesSeedBytes returns the number of bytes required
 // for seeding RandomBytesBufDeterministic.
 func RandomBytesSeedBytes() int {
@@ -0,0 +1,33 @@
+#include <sodium.h>
+#include "_cgo_export.h"
+
+static const char *
+go_reader_implementation_name(void)
+{
+    return "go_reader";
+}
+
+static uint32_t
+go_reader_random(void)
+{
+    uint32_t r;
+
+    goRandomBytesBuf(&r, sizeof r);
+
+    return r;
+}
+
+static void
+go_reader_buf(void * const buf, const size_t size)
+{
+    goRandomBytesBuf(buf, size);
+}
+
+struct randombytes_implementation go_reader_implementation = {
+    go_reader_implementation_name, /* implementation_name */
+    go_reader_random,              /* random */
+    NULL,                          /* stir */
+    NULL,                          /* uniform, falls back to random */
+    go_reader_buf,                 /* buf */
+    NULL                           /* close */
+};
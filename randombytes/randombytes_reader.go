@@ -0,0 +1,52 @@
+package randombytes
+
+// #cgo pkg-config: libsodium
+// #include <stdlib.h>
+// #include <sodium.h>
+// extern struct randombytes_implementation go_reader_implementation;
+import "C"
+import (
+	"github.com/GoKillers/libsodium-go/support"
+	"io"
+	"sync"
+	"unsafe"
+)
+
+var (
+	readerMu sync.Mutex
+	reader   io.Reader
+)
+
+// RandomBytesReaderImplementation contains a pointer to the implementation installed by
+// RandomBytesSetReader, which reads all random bytes from a Go io.Reader.
+var RandomBytesReaderImplementation *C.struct_randombytes_implementation = &C.go_reader_implementation
+
+// RandomBytesSetReader sets the implementation of the random number generator
+// to one that reads from `r`. All libsodium functions that need randomness,
+// including key generation, will use `r` from then on.
+// Reads from `r` are serialized, and the process panics if `r` returns an error,
+// as libsodium has no way to handle a failing entropy source.
+// The reader must not use libsodium's random number generator itself.
+func RandomBytesSetReader(r io.Reader) int {
+	support.NilPanic(r == nil, "reader")
+
+	readerMu.Lock()
+	reader = r
+	readerMu.Unlock()
+
+	return RandomBytesSetImplementation(RandomBytesReaderImplementation)
+}
+
+//export goRandomBytesBuf
+func goRandomBytesBuf(buf unsafe.Pointer, size C.size_t) {
+	if size == 0 {
+		return
+	}
+
+	readerMu.Lock()
+	defer readerMu.Unlock()
+
+	if _, err := io.ReadFull(reader, unsafe.Slice((*byte)(buf), size)); err != nil {
+		panic("randombytes: reading from reader failed: " + err.Error())
+	}
+}
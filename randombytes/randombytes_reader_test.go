@@ -0,0 +1,39 @@
+package randombytes
+
+import (
+	"bytes"
+	"testing"
+)
+
+// countingReader returns bytes with an increasing value.
+type countingReader struct {
+	n byte
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = r.n
+		r.n++
+	}
+	return len(p), nil
+}
+
+func TestRandomBytesSetReader(t *testing.T) {
+	defer RandomBytesSetImplementation(RandomBytesSysRandomImplementation)
+
+	if exit := RandomBytesSetReader(&countingReader{}); exit != 0 {
+		t.Fatalf("RandomBytesSetReader failed: %v", exit)
+	}
+
+	if name := RandomBytesImplementationName(); name != "go_reader" {
+		t.Errorf("Unexpected implementation name %q", name)
+	}
+
+	if b := RandomBytes(4); !bytes.Equal(b, []byte{0, 1, 2, 3}) {
+		t.Errorf("Unexpected random bytes %v", b)
+	}
+
+	if r := RandomBytesRandom(); r != 0x07060504 {
+		t.Errorf("Unexpected random number %x", r)
+	}
+}
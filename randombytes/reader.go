@@ -0,0 +1,88 @@
+package randombytes
+
+// #cgo pkg-config: libsodium
+// #include <stdlib.h>
+// #include <sodium.h>
+import "C"
+import (
+	"encoding/binary"
+	"github.com/GoKillers/libsodium-go/support"
+	"io"
+)
+
+// Reader is a global, shared instance of a cryptographically secure random
+// number generator backed by RandomBytesBuf. It can be used anywhere the
+// standard library expects crypto/rand.Reader.
+var Reader io.Reader = randomReader{}
+
+type randomReader struct{}
+
+// Read fills p with random bytes. It never fails.
+func (randomReader) Read(p []byte) (int, error) {
+	RandomBytesBuf(p)
+	return len(p), nil
+}
+
+// deterministicChunkSize is the number of bytes generated per seed by a DeterministicReader.
+const deterministicChunkSize = 1 << 16
+
+// DeterministicReader is an io.Reader that returns an endless stream of bytes
+// that are indistinguishable from random bytes without knowing the seed.
+// The same seed always results in the same stream, regardless of how it is read.
+// A DeterministicReader must not be used concurrently.
+type DeterministicReader struct {
+	seed    []byte
+	counter uint64
+	buf     []byte
+}
+
+// NewDeterministicReader returns a DeterministicReader for a seed of RandomBytesSeedBytes() bytes.
+//
+// The stream is generated in chunks using RandomBytesBufDeterministic,
+// each seeded with a subkey that is derived from the seed and a chunk counter.
+func NewDeterministicReader(seed []byte) *DeterministicReader {
+	support.CheckSize(seed, RandomBytesSeedBytes(), "seed")
+
+	r := &DeterministicReader{seed: make([]byte, len(seed))}
+	copy(r.seed, seed)
+
+	return r
+}
+
+// Read fills p with the next bytes from the stream. It never fails.
+func (r *DeterministicReader) Read(p []byte) (int, error) {
+	n := 0
+	for n < len(p) {
+		if len(r.buf) == 0 {
+			r.buf = r.chunk()
+		}
+
+		c := copy(p[n:], r.buf)
+		r.buf = r.buf[c:]
+		n += c
+	}
+
+	return n, nil
+}
+
+// chunk generates the next chunk of the stream.
+func (r *DeterministicReader) chunk() []byte {
+	var counter [8]byte
+	binary.LittleEndian.PutUint64(counter[:], r.counter)
+	r.counter++
+
+	// Derive the seed for this chunk as BLAKE2b(key = seed, message = counter)
+	seed := make([]byte, RandomBytesSeedBytes())
+	C.crypto_generichash(
+		(*C.uchar)(&seed[0]),
+		C.size_t(len(seed)),
+		(*C.uchar)(&counter[0]),
+		C.ulonglong(len(counter)),
+		(*C.uchar)(&r.seed[0]),
+		C.size_t(len(r.seed)))
+
+	chunk := make([]byte, deterministicChunkSize)
+	RandomBytesBufDeterministic(chunk, seed)
+
+	return chunk
+}
@@ -0,0 +1,60 @@
+package randombytes
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"io"
+	"testing"
+)
+
+func TestReader(t *testing.T) {
+	a := make([]byte, 64)
+	b := make([]byte, 64)
+
+	if _, err := io.ReadFull(Reader, a); err != nil {
+		t.Fatal(err)
+	}
+	io.ReadFull(Reader, b)
+
+	if bytes.Equal(a, b) {
+		t.Error("Reader returned the same bytes twice")
+	}
+
+	if _, _, err := ed25519.GenerateKey(Reader); err != nil {
+		t.Errorf("Key generation failed: %v", err)
+	}
+}
+
+func TestDeterministicReader(t *testing.T) {
+	seed := RandomBytes(RandomBytesSeedBytes())
+
+	// Reading in different chunk sizes results in the same stream
+	a := make([]byte, 3*deterministicChunkSize+5)
+	io.ReadFull(NewDeterministicReader(seed), a)
+
+	b := make([]byte, len(a))
+	r := NewDeterministicReader(seed)
+	for i := 0; i < len(b); i += 1000 {
+		end := i + 1000
+		if end > len(b) {
+			end = len(b)
+		}
+		r.Read(b[i:end])
+	}
+
+	if !bytes.Equal(a, b) {
+		t.Fatal("Deterministic streams differ")
+	}
+
+	// The first chunk differs from the following chunks
+	if bytes.Equal(a[:64], a[deterministicChunkSize:deterministicChunkSize+64]) {
+		t.Fatal("Chunks repeat")
+	}
+
+	// Deterministic keys
+	pk1, _, _ := ed25519.GenerateKey(NewDeterministicReader(seed))
+	pk2, _, _ := ed25519.GenerateKey(NewDeterministicReader(seed))
+	if !bytes.Equal(pk1, pk2) {
+		t.Error("Deterministic key generation differs")
+	}
+}
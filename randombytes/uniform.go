@@ -0,0 +1,114 @@
+package randombytes
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// random64 returns a random 64 bit unsigned integer.
+func random64() uint64 {
+	var b [8]byte
+	RandomBytesBuf(b[:])
+	return binary.LittleEndian.Uint64(b[:])
+}
+
+// Uint64n returns a uniformly distributed random number in [0, n).
+// It panics if n is zero.
+func Uint64n(n uint64) uint64 {
+	if n == 0 {
+		panic("invalid argument to Uint64n")
+	}
+
+	switch {
+	case n <= math.MaxUint32:
+		return uint64(RandomBytesUniform(uint32(n)))
+	case n&(n-1) == 0:
+		// Powers of two need no rejection
+		return random64() & (n - 1)
+	}
+
+	// Reject values below 2^64 mod n, so that the remaining
+	// range is a multiple of n and the result is unbiased.
+	min := -n % n
+	for {
+		if r := random64(); r >= min {
+			return r % n
+		}
+	}
+}
+
+// Int63n returns a uniformly distributed random number in [0, n).
+// It panics if n <= 0.
+func Int63n(n int64) int64 {
+	if n <= 0 {
+		panic("invalid argument to Int63n")
+	}
+	return int64(Uint64n(uint64(n)))
+}
+
+// Intn returns a uniformly distributed random number in [0, n).
+// It panics if n <= 0.
+func Intn(n int) int {
+	if n <= 0 {
+		panic("invalid argument to Intn")
+	}
+	return int(Uint64n(uint64(n)))
+}
+
+// Shuffle randomizes the order of n elements using the Fisher-Yates algorithm.
+// The swap function swaps the elements with indexes i and j.
+// It panics if n < 0.
+func Shuffle(n int, swap func(i, j int)) {
+	if n < 0 {
+		panic("invalid argument to Shuffle")
+	}
+
+	for i := n - 1; i > 0; i-- {
+		swap(i, Intn(i+1))
+	}
+}
+
+// Sample returns k distinct random numbers in [0, n), in random order.
+// Every subset of size k is equally likely.
+// It panics if k < 0, n < 0 or k > n.
+func Sample(n, k int) []int {
+	if k < 0 || n < 0 || k > n {
+		panic("invalid argument to Sample")
+	}
+
+	// Robert Floyd's algorithm, which needs k random numbers regardless of n
+	res := make([]int, 0, k)
+	seen := make(map[int]bool, k)
+	for j := n - k; j < n; j++ {
+		t := Intn(j + 1)
+		if seen[t] {
+			t = j
+		}
+		seen[t] = true
+		res = append(res, t)
+	}
+
+	Shuffle(len(res), func(i, j int) {
+		res[i], res[j] = res[j], res[i]
+	})
+
+	return res
+}
+
+// Token returns a random string of `length` characters, each chosen uniformly
+// from the characters of `alphabet`. The alphabet should not contain duplicate characters,
+// as those would be chosen more often.
+// It panics if the alphabet is empty or length < 0.
+func Token(length int, alphabet string) string {
+	chars := []rune(alphabet)
+	if len(chars) == 0 || length < 0 {
+		panic("invalid argument to Token")
+	}
+
+	token := make([]rune, length)
+	for i := range token {
+		token[i] = chars[RandomBytesUniform(uint32(len(chars)))]
+	}
+
+	return string(token)
+}
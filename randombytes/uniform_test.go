@@ -0,0 +1,78 @@
+package randombytes
+
+import (
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestUint64n(t *testing.T) {
+	for _, n := range []uint64{1, 2, 3, 1 << 32, 1<<32 + 1, 1 << 63, 1<<63 + 1, ^uint64(0)} {
+		for i := 0; i < 100; i++ {
+			if r := Uint64n(n); r >= n {
+				t.Fatalf("Uint64n(%v) returned %v", n, r)
+			}
+		}
+	}
+
+	// Rough check of the distribution
+	var counts [10]int
+	for i := 0; i < 100000; i++ {
+		counts[Int63n(10)]++
+	}
+	for i, c := range counts {
+		if c < 9000 || c > 11000 {
+			t.Errorf("Value %v occurred %v times out of 100000", i, c)
+		}
+	}
+}
+
+func TestShuffle(t *testing.T) {
+	s := []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}
+	Shuffle(len(s), func(i, j int) {
+		s[i], s[j] = s[j], s[i]
+	})
+
+	sort.Ints(s)
+	for i, v := range s {
+		if i != v {
+			t.Fatalf("Shuffle lost elements: %v", s)
+		}
+	}
+}
+
+func TestSample(t *testing.T) {
+	for _, k := range []int{0, 1, 5, 100} {
+		s := Sample(100, k)
+		if len(s) != k {
+			t.Fatalf("Sample returned %v elements, expected %v", len(s), k)
+		}
+
+		seen := make(map[int]bool)
+		for _, v := range s {
+			if v < 0 || v >= 100 || seen[v] {
+				t.Fatalf("Invalid sample: %v", s)
+			}
+			seen[v] = true
+		}
+	}
+}
+
+func TestToken(t *testing.T) {
+	alphabet := "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+	token := Token(16, alphabet)
+
+	if len(token) != 16 {
+		t.Fatalf("Token has length %v", len(token))
+	}
+
+	for _, c := range token {
+		if !strings.ContainsRune(alphabet, c) {
+			t.Fatalf("Token contains invalid character %q", c)
+		}
+	}
+
+	if len([]rune(Token(4, "äöü"))) != 4 {
+		t.Error("Token does not handle multi-byte characters")
+	}
+}
@@ -0,0 +1,393 @@
+// Package secureconn implements authenticated encrypted connections over a
+// net.Conn, using session keys established by a key exchange.
+//
+// Writes are split into records of at most RecordSize bytes of plaintext,
+// each encrypted with XChaCha20-Poly1305-IETF. A record consists of a 4-byte
+// header, holding the record type and the big-endian 24-bit length of the
+// ciphertext, followed by the ciphertext. The header is authenticated as
+// additional data, and every record uses the next nonce of a counter, so
+// records can not be modified, reordered, replayed or dropped.
+//
+// Both directions use their own key, which is replaced by a key derived from it
+// after RekeyBytes bytes of plaintext. Close and CloseWrite send a final record,
+// so that the peer can tell a closed connection from a truncated one.
+package secureconn
+
+import (
+	"encoding/binary"
+	"errors"
+	"github.com/GoKillers/libsodium-go/crypto/aead/xchacha20poly1305ietf"
+	generichash "github.com/GoKillers/libsodium-go/cryptogenerichash"
+	"github.com/GoKillers/libsodium-go/support"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+// Sizes and limits.
+const (
+	KeyBytes          = xchacha20poly1305ietf.KeyBytes            // Size of a session key in bytes
+	Overhead          = headerSize + xchacha20poly1305ietf.ABytes // Size added to the plaintext of a record
+	DefaultRecordSize = 16 * 1024                                 // Default maximum size of the plaintext of a record
+	MaxRecordSize     = 1 << 20                                   // Limit of the maximum size of the plaintext of a record
+	DefaultRekeyBytes = 1 << 30                                   // Default number of bytes after which a key is replaced
+)
+
+// headerSize is the size of the header of a record.
+const headerSize = 4
+
+// Record types.
+const (
+	recordData  = 0
+	recordClose = 1
+)
+
+// closeTimeout limits the time Close spends on sending the final record.
+const closeTimeout = 5 * time.Second
+
+// rekeyContext is hashed with a key to derive the key that replaces it.
+const rekeyContext = "secureconn rekey"
+
+var errInvalidRecord = errors.New("secureconn: invalid record")
+
+// Config configures a connection. The zero value selects the defaults.
+// Both ends of a connection must use the same configuration.
+type Config struct {
+	RecordSize int   // Maximum size of the plaintext of a record, DefaultRecordSize if zero
+	RekeyBytes int64 // Bytes of plaintext after which a key is replaced, DefaultRekeyBytes if zero, never if negative
+}
+
+// withDefaults returns a copy of the configuration with the defaults filled in,
+// or an error if the configuration is invalid.
+func (c *Config) withDefaults() (Config, error) {
+	var cfg Config
+	if c != nil {
+		cfg = *c
+	}
+
+	if cfg.RecordSize == 0 {
+		cfg.RecordSize = DefaultRecordSize
+	}
+
+	if cfg.RecordSize < 1 || cfg.RecordSize > MaxRecordSize {
+		return cfg, errors.New("secureconn: invalid record size")
+	}
+
+	if cfg.RekeyBytes == 0 {
+		cfg.RekeyBytes = DefaultRekeyBytes
+	}
+
+	return cfg, nil
+}
+
+// halfConn holds the state of one direction of a connection.
+type halfConn struct {
+	key        [KeyBytes]byte
+	nonce      [xchacha20poly1305ietf.NonceBytes]byte
+	seq        uint64 // Number of records since the last rekey
+	bytes      int64  // Bytes of plaintext since the last rekey
+	rekeyBytes int64
+}
+
+// setNonce writes the little-endian record counter to the nonce.
+func (h *halfConn) setNonce() {
+	binary.LittleEndian.PutUint64(h.nonce[:], h.seq)
+}
+
+// seal appends the encrypted record `m` with header `hdr` to `dst`.
+func (h *halfConn) seal(dst, hdr, m []byte) []byte {
+	h.setNonce()
+	dst = xchacha20poly1305ietf.Seal(dst, m, hdr, &h.nonce, &h.key)
+	h.advance(len(m))
+	return dst
+}
+
+// open appends the decrypted ciphertext `c` with header `hdr` to `dst`.
+func (h *halfConn) open(dst, hdr, c []byte) ([]byte, error) {
+	h.setNonce()
+	m, err := xchacha20poly1305ietf.Open(dst, c, hdr, &h.nonce, &h.key)
+	if err != nil {
+		return nil, err
+	}
+
+	h.advance(len(m) - len(dst))
+	return m, nil
+}
+
+// advance moves to the next record after `n` bytes of plaintext, replacing the
+// key once the configured number of bytes is reached or the counter wraps.
+func (h *halfConn) advance(n int) {
+	h.seq++
+	h.bytes += int64(n)
+
+	if h.seq != 0 && (h.rekeyBytes < 0 || h.bytes < h.rekeyBytes) {
+		return
+	}
+
+	k, _ := generichash.CryptoGenericHash(KeyBytes, []byte(rekeyContext), h.key[:])
+	copy(h.key[:], k)
+	h.seq = 0
+	h.bytes = 0
+}
+
+// Conn is an authenticated encrypted connection. It implements net.Conn and
+// is safe for concurrent use like the connection it wraps.
+type Conn struct {
+	conn       net.Conn
+	recordSize int
+
+	rmu   sync.Mutex
+	in    halfConn
+	raw   []byte // Received data that is not yet decrypted
+	plain []byte // Decrypted data that is not yet read
+	pbuf  []byte // Storage of plain
+	rerr  error
+
+	wmu  sync.Mutex
+	out  halfConn
+	wbuf []byte
+	werr error
+}
+
+// NewSecureConn returns a connection that encrypts the data written to `conn`
+// with `txKey`, and decrypts the data read from it with `rxKey`, using the
+// default configuration. The keys must differ and must not be used for any
+// other connection; the peer uses them the other way around.
+func NewSecureConn(conn net.Conn, rxKey, txKey *[KeyBytes]byte) *Conn {
+	c, err := NewSecureConnConfig(conn, rxKey, txKey, nil)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// NewSecureConnConfig is like NewSecureConn with a configuration.
+func NewSecureConnConfig(conn net.Conn, rxKey, txKey *[KeyBytes]byte, config *Config) (*Conn, error) {
+	support.NilPanic(conn == nil, "connection")
+	support.NilPanic(rxKey == nil, "receive key")
+	support.NilPanic(txKey == nil, "transmit key")
+
+	if *rxKey == *txKey {
+		panic("secureconn: receive and transmit keys are equal")
+	}
+
+	cfg, err := config.withDefaults()
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Conn{
+		conn:       conn,
+		recordSize: cfg.RecordSize,
+		in:         halfConn{key: *rxKey, rekeyBytes: cfg.RekeyBytes},
+		out:        halfConn{key: *txKey, rekeyBytes: cfg.RekeyBytes},
+	}
+
+	return c, nil
+}
+
+// NetConn returns the underlying connection.
+func (c *Conn) NetConn() net.Conn {
+	return c.conn
+}
+
+// Read reads decrypted data from the connection.
+//
+// It returns io.EOF once the peer has closed the connection, and
+// io.ErrUnexpectedEOF if the connection ended without a final record. A record
+// that fails authentication closes the connection and returns a
+// *support.VerificationError from then on. Timeouts are not fatal: a read may
+// be retried after extending the deadline.
+func (c *Conn) Read(b []byte) (int, error) {
+	c.rmu.Lock()
+	defer c.rmu.Unlock()
+
+	if len(b) == 0 {
+		return 0, nil
+	}
+
+	for len(c.plain) == 0 {
+		if c.rerr != nil {
+			return 0, c.rerr
+		}
+
+		if err := c.readRecord(); err != nil {
+			return 0, err
+		}
+	}
+
+	n := copy(b, c.plain)
+	c.plain = c.plain[n:]
+
+	return n, nil
+}
+
+// readRecord reads and decrypts the next record.
+func (c *Conn) readRecord() error {
+	if err := c.fill(headerSize); err != nil {
+		return err
+	}
+
+	typ := c.raw[0]
+	n := int(c.raw[1])<<16 | int(c.raw[2])<<8 | int(c.raw[3])
+	if typ > recordClose || n < xchacha20poly1305ietf.ABytes || n > xchacha20poly1305ietf.ABytes+c.recordSize {
+		return c.fail(errInvalidRecord)
+	}
+
+	if err := c.fill(headerSize + n); err != nil {
+		return err
+	}
+
+	m, err := c.in.open(c.pbuf[:0], c.raw[:headerSize], c.raw[headerSize:headerSize+n])
+	if err != nil {
+		return c.fail(err)
+	}
+
+	c.raw = c.raw[:copy(c.raw, c.raw[headerSize+n:])]
+	c.pbuf = m[:0]
+	c.plain = m
+
+	if typ == recordClose {
+		if len(m) != 0 {
+			return c.fail(errInvalidRecord)
+		}
+
+		c.rerr = io.EOF
+		return io.EOF
+	}
+
+	return nil
+}
+
+// fill reads from the connection until at least `n` bytes are buffered.
+// Timeouts leave the buffered data in place, other errors are permanent.
+func (c *Conn) fill(n int) error {
+	if cap(c.raw) < n {
+		raw := make([]byte, len(c.raw), n)
+		copy(raw, c.raw)
+		c.raw = raw
+	}
+
+	for len(c.raw) < n {
+		m, err := c.conn.Read(c.raw[len(c.raw):cap(c.raw)])
+		c.raw = c.raw[:len(c.raw)+m]
+
+		if err == nil || len(c.raw) >= n {
+			continue
+		}
+
+		if ne, ok := err.(net.Error); ok && ne.Timeout() {
+			return err
+		}
+
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+
+		c.rerr = err
+		return err
+	}
+
+	return nil
+}
+
+// fail closes the connection after an invalid record and makes `err` permanent.
+func (c *Conn) fail(err error) error {
+	c.rerr = err
+	c.raw = nil
+	c.plain = nil
+	c.conn.Close()
+	return err
+}
+
+// Write encrypts and writes data to the connection. Any error, including a
+// timeout, is permanent, as a record may have been written in part.
+func (c *Conn) Write(b []byte) (int, error) {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+
+	var n int
+	for len(b) > 0 {
+		m := b
+		if len(m) > c.recordSize {
+			m = m[:c.recordSize]
+		}
+
+		if err := c.writeRecord(recordData, m); err != nil {
+			return n, err
+		}
+
+		n += len(m)
+		b = b[len(m):]
+	}
+
+	return n, nil
+}
+
+// writeRecord encrypts and writes a record.
+func (c *Conn) writeRecord(typ byte, m []byte) error {
+	if c.werr != nil {
+		return c.werr
+	}
+
+	n := len(m) + xchacha20poly1305ietf.ABytes
+	hdr := [headerSize]byte{typ, byte(n >> 16), byte(n >> 8), byte(n)}
+
+	c.wbuf = c.out.seal(append(c.wbuf[:0], hdr[:]...), hdr[:], m)
+
+	if _, err := c.conn.Write(c.wbuf); err != nil {
+		c.werr = err
+		return err
+	}
+
+	return nil
+}
+
+// CloseWrite sends a final record to the peer, after which no more data can be
+// written. Data can still be read until the peer closes its side.
+func (c *Conn) CloseWrite() error {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+
+	err := c.writeRecord(recordClose, nil)
+	if c.werr == nil {
+		c.werr = net.ErrClosed
+	}
+
+	return err
+}
+
+// Close sends a final record to the peer and closes the connection. Sending
+// the final record is given up after a few seconds, ending writes in progress.
+func (c *Conn) Close() error {
+	c.conn.SetWriteDeadline(time.Now().Add(closeTimeout))
+	c.CloseWrite()
+	return c.conn.Close()
+}
+
+// LocalAddr returns the local network address.
+func (c *Conn) LocalAddr() net.Addr {
+	return c.conn.LocalAddr()
+}
+
+// RemoteAddr returns the remote network address.
+func (c *Conn) RemoteAddr() net.Addr {
+	return c.conn.RemoteAddr()
+}
+
+// SetDeadline sets the read and write deadlines of the underlying connection.
+func (c *Conn) SetDeadline(t time.Time) error {
+	return c.conn.SetDeadline(t)
+}
+
+// SetReadDeadline sets the read deadline of the underlying connection.
+func (c *Conn) SetReadDeadline(t time.Time) error {
+	return c.conn.SetReadDeadline(t)
+}
+
+// SetWriteDeadline sets the write deadline of the underlying connection.
+// A write that times out breaks the connection.
+func (c *Conn) SetWriteDeadline(t time.Time) error {
+	return c.conn.SetWriteDeadline(t)
+}
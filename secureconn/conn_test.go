@@ -0,0 +1,316 @@
+package secureconn
+
+import (
+	"bytes"
+	"github.com/GoKillers/libsodium-go/crypto/aead/xchacha20poly1305ietf"
+	"github.com/GoKillers/libsodium-go/randombytes"
+	"github.com/GoKillers/libsodium-go/support"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+func generateKeys() (k1, k2 *[KeyBytes]byte) {
+	return xchacha20poly1305ietf.GenerateKey(), xchacha20poly1305ietf.GenerateKey()
+}
+
+// pipe returns two connected secure connections.
+func pipe(t *testing.T, config *Config) (*Conn, *Conn) {
+	k1, k2 := generateKeys()
+	p1, p2 := net.Pipe()
+
+	c1, err := NewSecureConnConfig(p1, k1, k2, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c2, err := NewSecureConnConfig(p2, k2, k1, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		p1.Close()
+		p2.Close()
+	})
+
+	return c1, c2
+}
+
+// writeAsync writes `data` to `c` and closes it for writing, reporting the
+// error on the returned channel.
+func writeAsync(c *Conn, data []byte) <-chan error {
+	done := make(chan error, 1)
+	go func() {
+		_, err := c.Write(data)
+		if err == nil {
+			err = c.CloseWrite()
+		}
+		done <- err
+	}()
+	return done
+}
+
+func TestConn(t *testing.T) {
+	configs := []*Config{
+		nil,
+		{RecordSize: 100, RekeyBytes: 1000},
+		{RecordSize: 1, RekeyBytes: -1},
+		{RecordSize: MaxRecordSize},
+	}
+
+	for _, config := range configs {
+		c1, c2 := pipe(t, config)
+		data := randombytes.RandomBytes(100000)
+
+		done := writeAsync(c1, data)
+
+		got, err := io.ReadAll(c2)
+		if err != nil {
+			t.Fatalf("%+v: %v", config, err)
+		}
+		if !bytes.Equal(got, data) {
+			t.Fatalf("%+v: incorrect data", config)
+		}
+		if err := <-done; err != nil {
+			t.Fatalf("%+v: %v", config, err)
+		}
+	}
+}
+
+func TestConnBidirectional(t *testing.T) {
+	c1, c2 := pipe(t, &Config{RecordSize: 512, RekeyBytes: 4096})
+	d1 := randombytes.RandomBytes(50000)
+	d2 := randombytes.RandomBytes(30000)
+
+	done1 := writeAsync(c1, d1)
+	done2 := writeAsync(c2, d2)
+
+	got1 := make(chan []byte)
+	go func() {
+		b, _ := io.ReadAll(c2)
+		got1 <- b
+	}()
+
+	got2, err := io.ReadAll(c1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(<-got1, d1) || !bytes.Equal(got2, d2) {
+		t.Fatal("incorrect data")
+	}
+
+	if err := <-done1; err != nil {
+		t.Fatal(err)
+	}
+	if err := <-done2; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRekey(t *testing.T) {
+	c1, c2 := pipe(t, &Config{RecordSize: 100, RekeyBytes: 150})
+	key := c1.out.key
+
+	go c1.Write(make([]byte, 300))
+
+	if _, err := io.ReadFull(c2, make([]byte, 300)); err != nil {
+		t.Fatal(err)
+	}
+
+	// The key is replaced after the second record
+	if c1.out.key == key {
+		t.Error("key was not replaced")
+	}
+	if c1.out.key != c2.in.key || c1.out.seq != 1 || c2.in.seq != 1 {
+		t.Error("keys are out of sync")
+	}
+}
+
+func TestConnTampered(t *testing.T) {
+	k1, k2 := generateKeys()
+	raw := sealRecord(k1, k2, "hello")
+
+	for i := range raw {
+		tampered := append([]byte(nil), raw...)
+		tampered[i] ^= 0x01
+
+		p1, p2 := net.Pipe()
+		c2 := NewSecureConn(p2, k2, k1)
+		go func() {
+			p1.Write(tampered)
+			p1.Close()
+		}()
+
+		_, err := c2.Read(make([]byte, 16))
+		if err == nil {
+			t.Fatalf("byte %d: tampered record accepted", i)
+		}
+		if i < headerSize {
+			continue
+		}
+
+		if !isVerificationError(err) {
+			t.Fatalf("byte %d: expected verification error, got %v", i, err)
+		}
+		if _, err := c2.Read(make([]byte, 16)); !isVerificationError(err) {
+			t.Fatalf("byte %d: read after failure returned %v", i, err)
+		}
+		if _, err := p2.Write([]byte{0}); err != io.ErrClosedPipe {
+			t.Fatalf("byte %d: connection not closed", i)
+		}
+	}
+}
+
+func TestConnWrongKey(t *testing.T) {
+	k1, k2 := generateKeys()
+	p1, p2 := net.Pipe()
+	defer p1.Close()
+
+	c1 := NewSecureConn(p1, k1, k2)
+	c2 := NewSecureConn(p2, k1, k2)
+
+	go c1.Write([]byte("hello"))
+
+	if _, err := c2.Read(make([]byte, 16)); !isVerificationError(err) {
+		t.Fatalf("expected verification error, got %v", err)
+	}
+}
+
+func TestConnReplay(t *testing.T) {
+	k1, k2 := generateKeys()
+	raw := sealRecord(k1, k2, "hello")
+
+	p1, p2 := net.Pipe()
+	defer p1.Close()
+
+	c2 := NewSecureConn(p2, k2, k1)
+	go p1.Write(append(raw, raw...))
+
+	buf := make([]byte, 16)
+	if n, err := c2.Read(buf); err != nil || string(buf[:n]) != "hello" {
+		t.Fatal(n, err)
+	}
+	if _, err := c2.Read(buf); !isVerificationError(err) {
+		t.Fatalf("expected verification error, got %v", err)
+	}
+}
+
+func TestConnOversizedRecord(t *testing.T) {
+	k1, k2 := generateKeys()
+	p1, p2 := net.Pipe()
+	defer p1.Close()
+
+	c2, _ := NewSecureConnConfig(p2, k2, k1, &Config{RecordSize: 16})
+	go p1.Write([]byte{recordData, 0, 0, byte(16 + xchacha20poly1305ietf.ABytes + 1)})
+
+	if _, err := c2.Read(make([]byte, 64)); err != errInvalidRecord {
+		t.Fatalf("expected invalid record, got %v", err)
+	}
+}
+
+func TestConnTruncated(t *testing.T) {
+	c1, c2 := pipe(t, nil)
+
+	go func() {
+		c1.Write([]byte("hello"))
+		c1.conn.Close()
+	}()
+
+	if _, err := io.ReadAll(c2); err != io.ErrUnexpectedEOF {
+		t.Fatalf("expected unexpected EOF, got %v", err)
+	}
+}
+
+func TestConnDeadline(t *testing.T) {
+	k1, k2 := generateKeys()
+	raw := sealRecord(k1, k2, "hello")
+
+	p1, p2 := net.Pipe()
+	defer p1.Close()
+
+	c1 := NewSecureConn(p1, k2, k1)
+	c2 := NewSecureConn(p2, k2, k1)
+
+	c2.SetReadDeadline(time.Now().Add(10 * time.Millisecond))
+	go p1.Write(raw[:2])
+
+	_, err := c2.Read(make([]byte, 16))
+	if ne, ok := err.(net.Error); !ok || !ne.Timeout() {
+		t.Fatalf("expected timeout, got %v", err)
+	}
+
+	// The record that was cut short is completed by the next read
+	c2.SetReadDeadline(time.Time{})
+	go p1.Write(raw[2:])
+
+	buf := make([]byte, 16)
+	if n, err := c2.Read(buf); err != nil || string(buf[:n]) != "hello" {
+		t.Fatal(n, err)
+	}
+
+	c1.SetWriteDeadline(time.Now().Add(10 * time.Millisecond))
+	if _, err := c1.Write([]byte("hello")); err == nil {
+		t.Fatal("expected timeout")
+	}
+
+	c1.SetWriteDeadline(time.Time{})
+	if _, err := c1.Write([]byte("hello")); err == nil {
+		t.Fatal("write after timeout")
+	}
+}
+
+func TestConnClose(t *testing.T) {
+	c1, c2 := pipe(t, nil)
+
+	go c1.Close()
+
+	if _, err := c2.Read(make([]byte, 16)); err != io.EOF {
+		t.Fatalf("expected EOF, got %v", err)
+	}
+	if _, err := c1.Write([]byte("hello")); err == nil {
+		t.Fatal("write after close")
+	}
+}
+
+func TestConfig(t *testing.T) {
+	k1, k2 := generateKeys()
+	p1, _ := net.Pipe()
+
+	for _, size := range []int{-1, MaxRecordSize + 1} {
+		if _, err := NewSecureConnConfig(p1, k1, k2, &Config{RecordSize: size}); err == nil {
+			t.Errorf("record size %d accepted", size)
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("equal keys accepted")
+		}
+	}()
+	NewSecureConn(p1, k1, k1)
+}
+
+func isVerificationError(err error) bool {
+	_, ok := err.(*support.VerificationError)
+	return ok
+}
+
+// sealRecord returns the record of a message sent with transmit key `tx`.
+func sealRecord(rx, tx *[KeyBytes]byte, m string) []byte {
+	var c recordConn
+	NewSecureConn(&c, rx, tx).Write([]byte(m))
+	return c.buf.Bytes()
+}
+
+// recordConn is a net.Conn that records the data written to it.
+type recordConn struct {
+	net.Conn
+	buf bytes.Buffer
+}
+
+func (c *recordConn) Write(b []byte) (int, error) {
+	return c.buf.Write(b)
+}
@@ -0,0 +1,123 @@
+package sodium
+
+import (
+	"errors"
+	"fmt"
+	"github.com/GoKillers/libsodium-go/support"
+	"unsafe"
+)
+
+// #cgo pkg-config: libsodium
+// #include <stdlib.h>
+// #include <sodium.h>
+import "C"
+
+// Base64Variant selects the alphabet and padding of a Base64 encoding.
+type Base64Variant int
+
+// Base64 variants supported by Bin2Base64 and Base642Bin.
+const (
+	Base64VariantOriginal          Base64Variant = C.sodium_base64_VARIANT_ORIGINAL            // Standard alphabet with padding
+	Base64VariantOriginalNoPadding Base64Variant = C.sodium_base64_VARIANT_ORIGINAL_NO_PADDING // Standard alphabet without padding
+	Base64VariantURLSafe           Base64Variant = C.sodium_base64_VARIANT_URLSAFE             // URL-safe alphabet with padding
+	Base64VariantURLSafeNoPadding  Base64Variant = C.sodium_base64_VARIANT_URLSAFE_NO_PADDING  // URL-safe alphabet without padding
+)
+
+// valid reports whether v is one of the supported variants.
+// libsodium aborts the process when it is given any other value.
+func (v Base64Variant) valid() bool {
+	switch v {
+	case Base64VariantOriginal, Base64VariantOriginalNoPadding, Base64VariantURLSafe, Base64VariantURLSafeNoPadding:
+		return true
+	}
+	return false
+}
+
+// cString returns a C string for a Go string, or nil for an empty string.
+// The result must be freed with C.free.
+func cString(s string) *C.char {
+	if len(s) == 0 {
+		return nil
+	}
+	return C.CString(s)
+}
+
+// Hex2Bin decodes a hexadecimal string in constant time.
+// Characters in `ignore` are skipped, which allows parsing strings like "de:ad:be:ef".
+// An error is returned if the string contains any other invalid characters.
+func Hex2Bin(hex string, ignore string) ([]byte, error) {
+	h := []byte(hex)
+	bin := make([]byte, len(h)/2)
+	var binLen C.size_t
+
+	ign := cString(ignore)
+	defer C.free(unsafe.Pointer(ign))
+
+	exit := C.sodium_hex2bin(
+		(*C.uchar)(support.BytePointer(bin)),
+		C.size_t(len(bin)),
+		(*C.char)(unsafe.Pointer(support.BytePointer(h))),
+		C.size_t(len(h)),
+		ign,
+		&binLen,
+		nil)
+
+	if exit != 0 {
+		return nil, errors.New("sodium: invalid hexadecimal string")
+	}
+
+	return bin[:binLen], nil
+}
+
+// Bin2Base64 encodes a byte slice as Base64 using the given variant.
+// It panics if the variant is not supported.
+func Bin2Base64(bin []byte, variant Base64Variant) string {
+	if !variant.valid() {
+		panic(fmt.Sprintf("Unsupported Base64 variant (%d).", variant))
+	}
+
+	b64 := make([]byte, C.sodium_base64_encoded_len(C.size_t(len(bin)), C.int(variant)))
+
+	C.sodium_bin2base64(
+		(*C.char)(unsafe.Pointer(&b64[0])),
+		C.size_t(len(b64)),
+		(*C.uchar)(support.BytePointer(bin)),
+		C.size_t(len(bin)),
+		C.int(variant))
+
+	// Strip the terminating null byte
+	return string(b64[:len(b64)-1])
+}
+
+// Base642Bin decodes a Base64 string of the given variant in constant time.
+// Characters in `ignore` are skipped, which allows parsing strings containing whitespace.
+// An error is returned if the string contains any other invalid characters
+// or has incorrect padding, or if the variant is not supported.
+func Base642Bin(b64 string, ignore string, variant Base64Variant) ([]byte, error) {
+	if !variant.valid() {
+		return nil, errors.New("sodium: unsupported base64 variant")
+	}
+
+	b := []byte(b64)
+	bin := make([]byte, len(b)/4*3+2)
+	var binLen C.size_t
+
+	ign := cString(ignore)
+	defer C.free(unsafe.Pointer(ign))
+
+	exit := C.sodium_base642bin(
+		(*C.uchar)(&bin[0]),
+		C.size_t(len(bin)),
+		(*C.char)(unsafe.Pointer(support.BytePointer(b))),
+		C.size_t(len(b)),
+		ign,
+		&binLen,
+		nil,
+		C.int(variant))
+
+	if exit != 0 {
+		return nil, errors.New("sodium: invalid base64 string")
+	}
+
+	return bin[:binLen], nil
+}
@@ -0,0 +1,86 @@
+package sodium
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"testing"
+)
+
+func TestHex(t *testing.T) {
+	for _, in := range [][]byte{{}, {0}, {0xde, 0xad, 0xbe, 0xef}} {
+		h := Bin2hex(in)
+		if h != hex.EncodeToString(in) {
+			t.Errorf("Bin2hex(%v) = %q", in, h)
+		}
+
+		out, err := Hex2Bin(h, "")
+		if err != nil || !bytes.Equal(out, in) {
+			t.Errorf("Hex2Bin(%q) = %v, %v", h, out, err)
+		}
+	}
+
+	out, err := Hex2Bin("de:ad:be:ef", ":")
+	if err != nil || !bytes.Equal(out, []byte{0xde, 0xad, 0xbe, 0xef}) {
+		t.Errorf("Hex2Bin with ignored characters failed: %v, %v", out, err)
+	}
+
+	if _, err := Hex2Bin("de:ad", ""); err == nil {
+		t.Error("Hex2Bin accepted invalid characters")
+	}
+
+	if _, err := Hex2Bin("abc", ""); err == nil {
+		t.Error("Hex2Bin accepted an odd number of digits")
+	}
+}
+
+func TestBase64(t *testing.T) {
+	variants := map[Base64Variant]*base64.Encoding{
+		Base64VariantOriginal:          base64.StdEncoding,
+		Base64VariantOriginalNoPadding: base64.RawStdEncoding,
+		Base64VariantURLSafe:           base64.URLEncoding,
+		Base64VariantURLSafeNoPadding:  base64.RawURLEncoding,
+	}
+
+	for variant, enc := range variants {
+		for n := 0; n < 16; n++ {
+			in := bytes.Repeat([]byte{0xfb}, n)
+
+			b64 := Bin2Base64(in, variant)
+			if b64 != enc.EncodeToString(in) {
+				t.Errorf("Bin2Base64(%v, %v) = %q", in, variant, b64)
+			}
+
+			out, err := Base642Bin(b64, "", variant)
+			if err != nil || !bytes.Equal(out, in) {
+				t.Errorf("Base642Bin(%q, %v) = %v, %v", b64, variant, out, err)
+			}
+		}
+	}
+
+	out, err := Base642Bin("Zm9v\nYmFy", "\n", Base64VariantOriginal)
+	if err != nil || string(out) != "foobar" {
+		t.Errorf("Base642Bin with ignored characters failed: %v, %v", out, err)
+	}
+
+	if _, err := Base642Bin("Zm9vYg", "", Base64VariantOriginal); err == nil {
+		t.Error("Base642Bin accepted missing padding")
+	}
+}
+
+func TestBase64InvalidVariant(t *testing.T) {
+	for _, variant := range []Base64Variant{0, 2, 8, -1} {
+		if _, err := Base642Bin("Zm9v", "", variant); err == nil {
+			t.Errorf("Base642Bin accepted variant %d", variant)
+		}
+
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Bin2Base64 accepted variant %d", variant)
+				}
+			}()
+			Bin2Base64([]byte("hi"), variant)
+		}()
+	}
+}
@@ -1,16 +1,50 @@
 package sodium
 
-import "fmt"
+import (
+	"github.com/GoKillers/libsodium-go/support"
+	"sync"
+)
 
 // #cgo pkg-config: libsodium
 // #include <stdlib.h>
 // #include <sodium.h>
 import "C"
 
+var (
+	initOnce sync.Once
+	initErr  error
+)
+
+// Sodium should always be initialised
+func init() {
+	Initialize()
+}
+
+// Initialize initializes libsodium. It is safe to call Initialize
+// concurrently and more than once: the library is only initialized once,
+// and every call returns the result of that initialization.
+// All libsodium-go packages call Initialize when they are loaded.
+func Initialize() error {
+	initOnce.Do(func() {
+		// sodium_init returns 1 if the library was already initialized
+		if result := int(C.sodium_init()); result < 0 {
+			initErr = support.InitializationError(result)
+		}
+	})
+
+	return initErr
+}
+
+// Initialized returns true if libsodium was initialized successfully.
+func Initialized() bool {
+	return Initialize() == nil
+}
+
+// Init initializes libsodium and panics on failure.
+//
+// Deprecated: use Initialize instead.
 func Init() {
-	result := int(C.sodium_init())
-	if result != 0 {
-		panic(fmt.Sprintf("Sodium initialization failed, result code %d.",
-			result))
+	if err := Initialize(); err != nil {
+		panic(err)
 	}
 }
@@ -0,0 +1,28 @@
+package sodium
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestInitialize(t *testing.T) {
+	var wg sync.WaitGroup
+
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := Initialize(); err != nil {
+				t.Errorf("Initialization failed: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if !Initialized() {
+		t.Error("Library not initialized")
+	}
+
+	// Init no longer panics once initialized
+	Init()
+}
@@ -0,0 +1,104 @@
+package sodium
+
+import (
+	"errors"
+	"sync"
+	"unsafe"
+)
+
+// #cgo pkg-config: libsodium
+// #include <stdlib.h>
+// #include <sodium.h>
+import "C"
+
+// SecureBuffer is memory for secrets allocated with sodium_malloc.
+// The memory is outside the Go heap, so it is never copied by the runtime.
+// It is locked so that it is not swapped to disk, surrounded by guard
+// pages so that out of bounds accesses crash, and zeroed when freed.
+//
+// A SecureBuffer must be freed with Free. It is not freed when it is garbage
+// collected, since slices returned by Bytes may still refer to the memory.
+type SecureBuffer struct {
+	mu   sync.Mutex
+	ptr  unsafe.Pointer
+	size int
+}
+
+// NewSecureBuffer allocates a zeroed SecureBuffer of `size` bytes.
+// Allocating secure memory is slow and the amount of locked memory
+// is limited by the operating system, so it should be used sparingly.
+func NewSecureBuffer(size int) (*SecureBuffer, error) {
+	if size < 0 {
+		return nil, errors.New("sodium: negative size")
+	}
+
+	ptr := C.sodium_malloc(C.size_t(size))
+	if ptr == nil {
+		return nil, errors.New("sodium: unable to allocate secure memory")
+	}
+	C.sodium_memzero(ptr, C.size_t(size))
+
+	return &SecureBuffer{ptr: ptr, size: size}, nil
+}
+
+// Bytes returns the contents of the buffer. The slice refers to the secure
+// memory itself and must not be used after the buffer is freed, nor be read
+// or written when access to the memory is restricted.
+// It returns nil once the buffer is freed.
+func (b *SecureBuffer) Bytes() []byte {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.ptr == nil {
+		return nil
+	}
+
+	return unsafe.Slice((*byte)(b.ptr), b.size)
+}
+
+// Size returns the size of the buffer in bytes.
+func (b *SecureBuffer) Size() int {
+	return b.size
+}
+
+// Free zeroes and releases the memory. It is safe to call Free more than once.
+func (b *SecureBuffer) Free() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.ptr != nil {
+		C.sodium_free(b.ptr)
+		b.ptr = nil
+	}
+}
+
+// NoAccess makes the memory inaccessible, so that any access crashes the program.
+func (b *SecureBuffer) NoAccess() error {
+	return b.mprotect(func(p unsafe.Pointer) C.int { return C.sodium_mprotect_noaccess(p) })
+}
+
+// ReadOnly makes the memory read-only, so that writing to it crashes the program.
+func (b *SecureBuffer) ReadOnly() error {
+	return b.mprotect(func(p unsafe.Pointer) C.int { return C.sodium_mprotect_readonly(p) })
+}
+
+// ReadWrite makes the memory readable and writable again.
+func (b *SecureBuffer) ReadWrite() error {
+	return b.mprotect(func(p unsafe.Pointer) C.int { return C.sodium_mprotect_readwrite(p) })
+}
+
+// mprotect changes the protection of the memory using one of the sodium_mprotect functions.
+func (b *SecureBuffer) mprotect(f func(unsafe.Pointer) C.int) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.ptr == nil {
+		return errors.New("sodium: secure buffer already freed")
+	}
+
+	if f(b.ptr) != 0 {
+		return errors.New("sodium: unable to change memory protection")
+	}
+
+	return nil
+}
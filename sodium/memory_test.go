@@ -0,0 +1,39 @@
+package sodium
+
+import "testing"
+
+func TestSecureBuffer(t *testing.T) {
+	b, err := NewSecureBuffer(40)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf := b.Bytes()
+	if len(buf) != 40 || b.Size() != 40 || !IsZero(buf) {
+		t.Fatal("Secure buffer not zeroed")
+	}
+
+	copy(buf, "secret")
+
+	if err := b.ReadOnly(); err != nil {
+		t.Fatal(err)
+	}
+	if string(b.Bytes()[:6]) != "secret" {
+		t.Fatal("Read-only buffer can not be read")
+	}
+
+	if err := b.NoAccess(); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.ReadWrite(); err != nil {
+		t.Fatal(err)
+	}
+	b.Bytes()[0] = 'S'
+
+	b.Free()
+	b.Free()
+
+	if b.Bytes() != nil || b.ReadWrite() == nil {
+		t.Fatal("Freed buffer still usable")
+	}
+}
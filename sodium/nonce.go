@@ -0,0 +1,129 @@
+package sodium
+
+import (
+	"encoding/binary"
+	"errors"
+	"github.com/GoKillers/libsodium-go/support"
+	"sync"
+)
+
+// NonceSequence generates unique, monotonically increasing counter nonces
+// of a fixed size. The nonces are little-endian numbers, as used by Increment.
+// A NonceSequence is safe for concurrent use.
+type NonceSequence struct {
+	mu        sync.Mutex
+	next      []byte
+	exhausted bool
+}
+
+// NewNonceSequence returns a NonceSequence for nonces of `size` bytes, starting at zero.
+func NewNonceSequence(size int) *NonceSequence {
+	support.CheckIntInRange(size, 1, 1<<16, "nonce")
+	return &NonceSequence{next: make([]byte, size)}
+}
+
+// NewNonceSequenceAt returns a NonceSequence that starts at the given nonce.
+func NewNonceSequenceAt(start []byte) *NonceSequence {
+	s := NewNonceSequence(len(start))
+	copy(s.next, start)
+	return s
+}
+
+// Size returns the size of the generated nonces in bytes.
+func (s *NonceSequence) Size() int {
+	return len(s.next)
+}
+
+// Next returns the next nonce in the sequence.
+// A NonceExhaustedError is returned once every nonce has been used.
+func (s *NonceSequence) Next() ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.exhausted {
+		return nil, &support.NonceExhaustedError{}
+	}
+
+	n := make([]byte, len(s.next))
+	copy(n, s.next)
+
+	Increment(s.next)
+	s.exhausted = IsZero(s.next)
+
+	return n, nil
+}
+
+// Advance skips `n` nonces. It can be used after restoring a sequence from
+// a position that may be stale, for example when the position is persisted
+// only once per `n` nonces.
+// A NonceExhaustedError is returned if fewer than `n` nonces are left.
+func (s *NonceSequence) Advance(n uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if n == 0 {
+		return nil
+	}
+
+	if s.exhausted {
+		return &support.NonceExhaustedError{}
+	}
+
+	// Encode n in the size of the nonce, failing if it does not fit
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], n)
+	if len(s.next) < len(buf) && !IsZero(buf[len(s.next):]) {
+		s.exhausted = true
+		return &support.NonceExhaustedError{}
+	}
+
+	b := make([]byte, len(s.next))
+	copy(b, buf[:])
+
+	prev := make([]byte, len(s.next))
+	copy(prev, s.next)
+	Add(s.next, b)
+
+	// The counter wrapped around if it did not increase,
+	// which is only allowed when it ended exactly at the end.
+	if Compare(s.next, prev) <= 0 {
+		s.exhausted = true
+		if !IsZero(s.next) {
+			return &support.NonceExhaustedError{}
+		}
+	}
+
+	return nil
+}
+
+// MarshalBinary encodes the position of the sequence, so that it can be
+// persisted and restored with UnmarshalBinary. Restoring the position
+// guarantees that no nonce returned before the call to MarshalBinary is reused.
+func (s *NonceSequence) MarshalBinary() ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data := make([]byte, 1+len(s.next))
+	if s.exhausted {
+		data[0] = 1
+	}
+	copy(data[1:], s.next)
+
+	return data, nil
+}
+
+// UnmarshalBinary restores a position encoded by MarshalBinary.
+func (s *NonceSequence) UnmarshalBinary(data []byte) error {
+	if len(data) < 2 || data[0] > 1 {
+		return errors.New("sodium: invalid nonce sequence encoding")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.exhausted = data[0] == 1
+	s.next = make([]byte, len(data)-1)
+	copy(s.next, data[1:])
+
+	return nil
+}
@@ -0,0 +1,75 @@
+package sodium
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestNonceSequence(t *testing.T) {
+	s := NewNonceSequence(2)
+
+	// Nonces are unique and increasing
+	prev, err := s.Next()
+	if err != nil || !bytes.Equal(prev, []byte{0, 0}) {
+		t.Fatalf("Unexpected first nonce %v (%v)", prev, err)
+	}
+
+	for i := 1; i < 1<<16; i++ {
+		n, err := s.Next()
+		if err != nil {
+			t.Fatalf("Sequence exhausted after %v nonces", i)
+		}
+		if Compare(n, prev) != 1 {
+			t.Fatalf("Nonce %v is not greater than %v", n, prev)
+		}
+		prev = n
+	}
+
+	// All nonces have been used
+	if _, err := s.Next(); err == nil {
+		t.Fatal("Exhausted sequence returned a nonce")
+	}
+}
+
+func TestNonceSequenceAdvance(t *testing.T) {
+	s := NewNonceSequence(1)
+
+	if err := s.Advance(255); err != nil {
+		t.Fatalf("Advance failed: %v", err)
+	}
+
+	if n, err := s.Next(); err != nil || n[0] != 255 {
+		t.Fatalf("Unexpected nonce %v (%v)", n, err)
+	}
+
+	if err := NewNonceSequence(1).Advance(256); err == nil {
+		t.Fatal("Advance past the end succeeded")
+	}
+
+	if err := NewNonceSequenceAt([]byte{250}).Advance(10); err == nil {
+		t.Fatal("Advance past the end succeeded")
+	}
+}
+
+func TestNonceSequenceMarshal(t *testing.T) {
+	s := NewNonceSequence(24)
+	for i := 0; i < 1000; i++ {
+		s.Next()
+	}
+
+	data, err := s.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := new(NonceSequence)
+	if err := r.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+
+	a, _ := s.Next()
+	b, _ := r.Next()
+	if !bytes.Equal(a, b) || r.Size() != 24 {
+		t.Fatalf("Restored sequence differs: %v != %v", a, b)
+	}
+}
@@ -0,0 +1,52 @@
+package sodium
+
+import (
+	"errors"
+	"github.com/GoKillers/libsodium-go/support"
+	"math"
+)
+
+// #cgo pkg-config: libsodium
+// #include <stdlib.h>
+// #include <sodium.h>
+import "C"
+
+// Pad returns a copy of `buf` padded to a multiple of `blockSize` bytes
+// using the ISO/IEC 7816-4 padding algorithm.
+// At least one byte of padding is always added.
+func Pad(buf []byte, blockSize int) []byte {
+	support.CheckIntInRange(blockSize, 1, math.MaxInt32, "block")
+
+	padded := make([]byte, len(buf), len(buf)+blockSize)
+	copy(padded, buf)
+
+	var paddedLen C.size_t
+	C.sodium_pad(
+		&paddedLen,
+		(*C.uchar)(&padded[:cap(padded)][0]),
+		C.size_t(len(buf)),
+		C.size_t(blockSize),
+		C.size_t(cap(padded)))
+
+	return padded[:paddedLen]
+}
+
+// Unpad removes ISO/IEC 7816-4 padding for a block size of `blockSize` bytes.
+// The returned slice shares its storage with `buf`.
+// An error is returned if the padding is invalid.
+func Unpad(buf []byte, blockSize int) ([]byte, error) {
+	support.CheckIntInRange(blockSize, 1, math.MaxInt32, "block")
+
+	var unpaddedLen C.size_t
+	exit := C.sodium_unpad(
+		&unpaddedLen,
+		(*C.uchar)(support.BytePointer(buf)),
+		C.size_t(len(buf)),
+		C.size_t(blockSize))
+
+	if exit != 0 {
+		return nil, errors.New("sodium: invalid padding")
+	}
+
+	return buf[:unpaddedLen], nil
+}
@@ -0,0 +1,32 @@
+package sodium
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestPadding(t *testing.T) {
+	for _, blockSize := range []int{1, 16, 100} {
+		for n := 0; n < 3*blockSize; n++ {
+			in := bytes.Repeat([]byte{0x80}, n)
+
+			padded := Pad(in, blockSize)
+			if len(padded)%blockSize != 0 || len(padded) <= n {
+				t.Fatalf("Pad(%v, %v) returned %v bytes", n, blockSize, len(padded))
+			}
+
+			out, err := Unpad(padded, blockSize)
+			if err != nil || !bytes.Equal(out, in) {
+				t.Fatalf("Unpad failed for %v bytes with block size %v: %v", n, blockSize, err)
+			}
+		}
+	}
+
+	if _, err := Unpad(make([]byte, 16), 16); err == nil {
+		t.Error("Unpad accepted invalid padding")
+	}
+
+	if _, err := Unpad(nil, 16); err == nil {
+		t.Error("Unpad accepted an empty buffer")
+	}
+}
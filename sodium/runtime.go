@@ -16,3 +16,118 @@ func RuntimeHasSse2() bool {
 func RuntimeHasSse3() bool {
 	return C.sodium_runtime_has_sse3() != 0
 }
+
+// RuntimeHasSsse3 returns true if the CPU supports SSSE3
+func RuntimeHasSsse3() bool {
+	return C.sodium_runtime_has_ssse3() != 0
+}
+
+// RuntimeHasSse41 returns true if the CPU supports SSE4.1
+func RuntimeHasSse41() bool {
+	return C.sodium_runtime_has_sse41() != 0
+}
+
+// RuntimeHasAvx returns true if the CPU and operating system support AVX
+func RuntimeHasAvx() bool {
+	return C.sodium_runtime_has_avx() != 0
+}
+
+// RuntimeHasAvx2 returns true if the CPU and operating system support AVX2
+func RuntimeHasAvx2() bool {
+	return C.sodium_runtime_has_avx2() != 0
+}
+
+// RuntimeHasAvx512f returns true if the CPU and operating system support AVX-512F
+func RuntimeHasAvx512f() bool {
+	return C.sodium_runtime_has_avx512f() != 0
+}
+
+// RuntimeHasPclmul returns true if the CPU supports carry-less multiplication (PCLMULQDQ)
+func RuntimeHasPclmul() bool {
+	return C.sodium_runtime_has_pclmul() != 0
+}
+
+// RuntimeHasAesni returns true if the CPU supports the AES-NI instructions
+func RuntimeHasAesni() bool {
+	return C.sodium_runtime_has_aesni() != 0
+}
+
+// RuntimeHasRdrand returns true if the CPU supports the RDRAND instruction
+func RuntimeHasRdrand() bool {
+	return C.sodium_runtime_has_rdrand() != 0
+}
+
+// RuntimeHasArmCrypto returns true if the CPU supports the ARMv8 cryptography extensions
+func RuntimeHasArmCrypto() bool {
+	return C.sodium_runtime_has_armcrypto() != 0
+}
+
+// CPUFeatures summarizes the CPU features detected by libsodium.
+type CPUFeatures struct {
+	Neon      bool // ARM NEON
+	ArmCrypto bool // ARMv8 cryptography extensions
+	SSE2      bool
+	SSE3      bool
+	SSSE3     bool
+	SSE41     bool
+	AVX       bool
+	AVX2      bool
+	AVX512F   bool
+	PCLMUL    bool
+	AESNI     bool
+	RDRAND    bool
+
+	// AES256GCM is true if the hardware accelerated AES256-GCM implementation
+	// is available, see aes256gcm.IsAvailable.
+	AES256GCM bool
+}
+
+// Features returns the CPU features detected by libsodium.
+// Detection happens during initialization, so the library must be initialized first.
+func Features() CPUFeatures {
+	return CPUFeatures{
+		Neon:      RuntimeHasNeon(),
+		ArmCrypto: RuntimeHasArmCrypto(),
+		SSE2:      RuntimeHasSse2(),
+		SSE3:      RuntimeHasSse3(),
+		SSSE3:     RuntimeHasSsse3(),
+		SSE41:     RuntimeHasSse41(),
+		AVX:       RuntimeHasAvx(),
+		AVX2:      RuntimeHasAvx2(),
+		AVX512F:   RuntimeHasAvx512f(),
+		PCLMUL:    RuntimeHasPclmul(),
+		AESNI:     RuntimeHasAesni(),
+		RDRAND:    RuntimeHasRdrand(),
+		AES256GCM: C.crypto_aead_aes256gcm_is_available() != 0,
+	}
+}
+
+// BLAKE2b returns the name of the BLAKE2b implementation that libsodium
+// selects for these features: "avx2", "sse41", "ssse3" or "ref".
+func (f CPUFeatures) BLAKE2b() string {
+	switch {
+	case f.AVX2:
+		return "avx2"
+	case f.SSE41:
+		return "sse41"
+	case f.SSSE3:
+		return "ssse3"
+	default:
+		return "ref"
+	}
+}
+
+// Argon2 returns the name of the Argon2 implementation that libsodium
+// selects for these features: "avx512f", "avx2", "ssse3" or "ref".
+func (f CPUFeatures) Argon2() string {
+	switch {
+	case f.AVX512F:
+		return "avx512f"
+	case f.AVX2:
+		return "avx2"
+	case f.SSSE3:
+		return "ssse3"
+	default:
+		return "ref"
+	}
+}
@@ -0,0 +1,22 @@
+package sodium
+
+import (
+	"runtime"
+	"testing"
+)
+
+func TestFeatures(t *testing.T) {
+	f := Features()
+
+	t.Logf("CPU features: %+v", f)
+	t.Logf("BLAKE2b implementation: %s", f.BLAKE2b())
+	t.Logf("Argon2 implementation: %s", f.Argon2())
+
+	if runtime.GOARCH == "amd64" && !f.SSE2 {
+		t.Error("SSE2 not detected on amd64")
+	}
+
+	if f.AES256GCM && !(f.AESNI && f.PCLMUL || f.ArmCrypto) {
+		t.Error("AES256GCM available without hardware support")
+	}
+}
@@ -2,6 +2,7 @@ package sodium
 
 import "fmt"
 import "unsafe"
+import "github.com/GoKillers/libsodium-go/support"
 
 // #cgo pkg-config: libsodium
 // #include <stdlib.h>
@@ -14,19 +15,64 @@ func MemZero(buff1 []byte) {
 	}
 }
 
+// MemCmp compares the first `length` bytes of two byte slices in constant time.
+// It returns 0 if they are equal and -1 otherwise.
 func MemCmp(buff1, buff2 []byte, length int) int {
-	if length >= len(buff1) || length >= len(buff2) {
+	if length > len(buff1) || length > len(buff2) {
 		panic(fmt.Sprintf("Attempt to compare more bytes (%d) than provided "+
 			"(%d, %d)", length, len(buff1), len(buff2)))
 	}
-	return int(C.sodium_memcmp(unsafe.Pointer(&buff1[0]),
-		unsafe.Pointer(&buff2[0]),
+	return int(C.sodium_memcmp(unsafe.Pointer(support.BytePointer(buff1)),
+		unsafe.Pointer(support.BytePointer(buff2)),
 		C.size_t(length)))
 }
 
+// Compare compares two byte slices of equal length, interpreted as
+// little-endian numbers, in constant time.
+// It returns -1 if a < b, 0 if a == b and 1 if a > b.
+func Compare(a, b []byte) int {
+	support.CheckSize(b, len(a), "comparison")
+	return int(C.sodium_compare(
+		(*C.uchar)(support.BytePointer(a)),
+		(*C.uchar)(support.BytePointer(b)),
+		C.size_t(len(a))))
+}
+
+// IsZero returns true if a byte slice contains only zeros.
+// The check runs in constant time for a given length.
+func IsZero(n []byte) bool {
+	return C.sodium_is_zero((*C.uchar)(support.BytePointer(n)), C.size_t(len(n))) == 1
+}
+
+// Increment increments a byte slice, interpreted as a little-endian number,
+// by one in constant time. The number wraps around to zero on overflow.
+func Increment(n []byte) {
+	C.sodium_increment((*C.uchar)(support.BytePointer(n)), C.size_t(len(n)))
+}
+
+// Add adds two byte slices of equal length, interpreted as little-endian numbers,
+// in constant time and stores the result in `a`. The sum is computed modulo 2^(8*len(a)).
+func Add(a, b []byte) {
+	support.CheckSize(b, len(a), "addend")
+	C.sodium_add(
+		(*C.uchar)(support.BytePointer(a)),
+		(*C.uchar)(support.BytePointer(b)),
+		C.size_t(len(a)))
+}
+
+// Sub subtracts two byte slices of equal length, interpreted as little-endian numbers,
+// in constant time and stores the result in `a`. The difference is computed modulo 2^(8*len(a)).
+func Sub(a, b []byte) {
+	support.CheckSize(b, len(a), "subtrahend")
+	C.sodium_sub(
+		(*C.uchar)(support.BytePointer(a)),
+		(*C.uchar)(support.BytePointer(b)),
+		C.size_t(len(a)))
+}
+
 func Bin2hex(bin []byte) string {
 	maxlen := len(bin)*2 + 1
-	binPtr := (*C.uchar)(unsafe.Pointer(&bin[0]))
+	binPtr := (*C.uchar)(support.BytePointer(bin))
 	buf := (*C.char)(C.malloc(C.size_t(maxlen)))
 	defer C.free(unsafe.Pointer(buf))
 
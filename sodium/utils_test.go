@@ -0,0 +1,48 @@
+package sodium
+
+import "testing"
+
+func TestMemCmp(t *testing.T) {
+	a := []byte{1, 2, 3}
+	b := []byte{1, 2, 4}
+
+	if MemCmp(a, b, len(a)) == 0 {
+		t.Error("Different slices compared equal")
+	}
+
+	if MemCmp(a, b, 2) != 0 {
+		t.Error("Equal prefixes compared different")
+	}
+
+	if MemCmp(nil, nil, 0) != 0 {
+		t.Error("Empty slices compared different")
+	}
+}
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		a, b []byte
+		res  int
+	}{
+		{nil, nil, 0},
+		{[]byte{1, 2}, []byte{1, 2}, 0},
+		{[]byte{2, 1}, []byte{1, 2}, -1}, // little-endian: 0x0102 < 0x0201
+		{[]byte{1, 2}, []byte{2, 1}, 1},
+	}
+
+	for _, test := range tests {
+		if res := Compare(test.a, test.b); res != test.res {
+			t.Errorf("Compare(%v, %v) = %v, expected %v", test.a, test.b, res, test.res)
+		}
+	}
+}
+
+func TestIsZero(t *testing.T) {
+	if !IsZero(nil) || !IsZero(make([]byte, 32)) {
+		t.Error("Zero slice not detected as zero")
+	}
+
+	if IsZero([]byte{0, 0, 1}) {
+		t.Error("Non-zero slice detected as zero")
+	}
+}
@@ -30,3 +30,19 @@ type VerificationError struct {}
 func (k VerificationError) Error() string {
 	return "verification failed"
 }
+
+// NonceExhaustedError is an error that occurs when a nonce sequence
+// has no unused nonces left.
+type NonceExhaustedError struct{}
+
+func (k NonceExhaustedError) Error() string {
+	return "nonce sequence exhausted"
+}
+
+// InitializationError is an error that occurs when libsodium could not be initialized,
+// for example because no entropy source is available.
+type InitializationError int
+
+func (k InitializationError) Error() string {
+	return "sodium initialization failed with result code " + strconv.Itoa(int(k))
+}
@@ -59,6 +59,20 @@ func BytePointer(b []byte) *uint8 {
 	}
 }
 
+// SliceForAppend extends a byte slice by n bytes, reusing its storage when the capacity allows,
+// and returns the extended slice and a slice pointing to the n new bytes.
+// This allows append-style functions to write their output without allocating.
+func SliceForAppend(in []byte, n int) (head, tail []byte) {
+	if total := len(in) + n; cap(in) >= total {
+		head = in[:total]
+	} else {
+		head = make([]byte, total)
+		copy(head, in)
+	}
+	tail = head[len(in):]
+	return
+}
+
 // AlignedSlice returns a memory aligned slice
 func AlignedSlice(size, alignment int) []byte {
 	slice := make([]byte, size+alignment)